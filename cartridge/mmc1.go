@@ -1,7 +1,8 @@
-package main
+package cartridge
 
 import (
-	"fmt"
+	"github.com/scottferg/Fergulator/cpu"
+	"github.com/scottferg/Fergulator/ppu"
 )
 
 const (
@@ -10,8 +11,14 @@ const (
 )
 
 type Mmc1 struct {
-	RomBanks  [][]Word
-	VromBanks [][]Word
+	Cpu *cpu.Cpu
+	Ppu *ppu.Ppu
+
+	PrgWindows
+	ChrWindows
+
+	RomBanks  [][]byte
+	VromBanks [][]byte
 
 	PrgBankCount int
 	ChrRomCount  int
@@ -24,9 +31,24 @@ type Mmc1 struct {
 	PrgBankSize   int
 	ChrBankSize   int
 	Mirroring     int
+
+	// CPU cycle of the last write to the serial port
+	lastWrite uint64
 }
 
-func (m *Mmc1) Write(v Word, a int) {
+func (m *Mmc1) Write(a uint16, v byte) {
+	// The serial port ignores a write on the cycle straight after
+	// another, which is where the second write of a read-modify-write
+	// instruction lands
+	if m.Cpu != nil {
+		consecutive := m.Cpu.Cycles == m.lastWrite+1
+		m.lastWrite = m.Cpu.Cycles
+
+		if consecutive {
+			return
+		}
+	}
+
 	// If reset bit is set
 	if v&0x80 != 0 {
 		m.BufferCounter = 0
@@ -58,6 +80,45 @@ func (m *Mmc1) BatteryBacked() bool {
 	return m.Battery
 }
 
+type mmc1State struct {
+	Banks bankState
+
+	Buffer        uint8
+	BufferCounter uint8
+	PrgSwapBank   uint8
+	PrgBankSize   int32
+	ChrBankSize   int32
+	Mirroring     uint8
+}
+
+func (m *Mmc1) SaveState() []byte {
+	s := mmc1State{
+		Buffer:        uint8(m.Buffer),
+		BufferCounter: uint8(m.BufferCounter),
+		PrgSwapBank:   uint8(m.PrgSwapBank),
+		PrgBankSize:   int32(m.PrgBankSize),
+		ChrBankSize:   int32(m.ChrBankSize),
+		Mirroring:     uint8(m.Mirroring),
+	}
+	s.Banks.save(&m.PrgWindows, &m.ChrWindows)
+
+	return encodeState(&s)
+}
+
+func (m *Mmc1) LoadState(state []byte) {
+	var s mmc1State
+	decodeState(state, &s)
+
+	s.Banks.restore(m.Ppu, &m.PrgWindows, &m.ChrWindows)
+
+	m.Buffer = int(s.Buffer)
+	m.BufferCounter = uint(s.BufferCounter)
+	m.PrgSwapBank = int(s.PrgSwapBank)
+	m.PrgBankSize = int(s.PrgBankSize)
+	m.ChrBankSize = int(s.ChrBankSize)
+	m.Mirroring = int(s.Mirroring)
+}
+
 func (m *Mmc1) SetRegister(reg int, v int) {
 	switch reg {
 	// Control register
@@ -69,13 +130,13 @@ func (m *Mmc1) SetRegister(reg int, v int) {
 			m.Mirroring = tmp
 			switch m.Mirroring {
 			case 0x0:
-				ppu.Nametables.SetMirroring(MirroringSingleUpper)
+				m.Ppu.Nametables.SetMirroring(ppu.MirroringSingleUpper)
 			case 0x1:
-				ppu.Nametables.SetMirroring(MirroringSingleLower)
+				m.Ppu.Nametables.SetMirroring(ppu.MirroringSingleLower)
 			case 0x2:
-				ppu.Nametables.SetMirroring(MirroringVertical)
+				m.Ppu.Nametables.SetMirroring(ppu.MirroringVertical)
 			case 0x3:
-				ppu.Nametables.SetMirroring(MirroringHorizontal)
+				m.Ppu.Nametables.SetMirroring(ppu.MirroringHorizontal)
 			}
 		}
 
@@ -119,8 +180,7 @@ func (m *Mmc1) SetRegister(reg int, v int) {
 				bank = v & 0xF
 			}
 
-			WriteVramBank(m.VromBanks, bank, 0x0000, Size4k)
-			WriteVramBank(m.VromBanks, bank+1, 0x1000, Size4k)
+			m.mapChr(m.Ppu, bank*4, 0x0000, Size8k)
 		case Size4k:
 			// Swap 4k VROM
 			var bank int
@@ -130,7 +190,7 @@ func (m *Mmc1) SetRegister(reg int, v int) {
 			} else {
 				bank = v & 0xF
 			}
-			WriteVramBank(m.VromBanks, bank%m.ChrRomCount, 0x0, Size4k)
+			m.mapChr(m.Ppu, bank%m.ChrRomCount*4, 0x0000, Size4k)
 		}
 		// CHR Bank 1
 	case 2:
@@ -148,7 +208,7 @@ func (m *Mmc1) SetRegister(reg int, v int) {
 			} else {
 				bank = v & 0xF
 			}
-			WriteVramBank(m.VromBanks, bank%m.ChrRomCount, 0x1000, Size4k)
+			m.mapChr(m.Ppu, bank%m.ChrRomCount*4, 0x1000, Size4k)
 		}
 		// PRG Bank
 	case 3:
@@ -162,24 +222,21 @@ func (m *Mmc1) SetRegister(reg int, v int) {
 		case Size32k:
 			// Swap 32k ROM (in 32k mode, ignore first bit D0)
 			bank := ((v >> 0x1) & 0x7) * 2
-			fmt.Printf("32k write to: %d\n", bank/2)
-
-			WriteRamBank(m.RomBanks, bank, 0x8000, Size16k)
-			WriteRamBank(m.RomBanks, bank+1, 0xC000, Size16k)
+			m.mapPrg(bank*2, 0x8000, Size32k)
 		case Size16k:
 			// Swap 16k ROM
 			bank := v & 0xF
 
 			if m.PrgSwapBank == BankUpper {
-				WriteRamBank(m.RomBanks, bank, 0xC000, Size16k)
+				m.mapPrg(bank*2, 0xC000, Size16k)
 			} else {
-				WriteRamBank(m.RomBanks, bank, 0x8000, Size16k)
+				m.mapPrg(bank*2, 0x8000, Size16k)
 			}
 		}
 	}
 }
 
-func (m *Mmc1) RegisterNumber(a int) int {
+func (m *Mmc1) RegisterNumber(a uint16) int {
 	switch {
 	case a >= 0x8000 && a <= 0x9FFF:
 		return 0
@@ -0,0 +1,221 @@
+package cartridge
+
+import (
+	"github.com/scottferg/Fergulator/cpu"
+	"github.com/scottferg/Fergulator/ppu"
+	"testing"
+)
+
+func verifyMirroredValue(p *ppu.Ppu, a int, v byte, test *testing.T) {
+	if p.Nametables.ReadNametableData(a) != v {
+		test.Errorf("0x%X was 0x%X, expected 0x%X\n", a, p.Nametables.ReadNametableData(a), v)
+	}
+}
+
+func TestVerticalToHorizontal(test *testing.T) {
+	p := new(ppu.Ppu)
+	p.Init()
+
+	rom := &Mmc1{
+		Ppu:          p,
+		RomBanks:     make([][]byte, 16),
+		VromBanks:    make([][]byte, 16),
+		PrgBankCount: 8,
+		ChrRomCount:  8,
+		Battery:      false,
+		Data:         make([]byte, 32),
+		PrgSwapBank:  BankLower,
+	}
+
+	p.Nametables.SetMirroring(ppu.MirroringHorizontal)
+
+	if p.Nametables.Mirroring != ppu.MirroringHorizontal {
+		test.Errorf("Mirroring was not horizontal")
+	}
+
+	// Setup Vertical mirroring
+	rom.Write(0x8000, 0x0)
+	rom.Write(0x8000, 0x1)
+	rom.Write(0x8000, 0x0)
+	rom.Write(0x8000, 0x0)
+	rom.Write(0x8000, 0x0)
+
+	if p.Nametables.Mirroring != ppu.MirroringVertical {
+		test.Errorf("Mirroring was not vertical")
+	}
+
+	p.VramAddress = 0x2000
+	p.WriteData(0x11)
+	p.VramAddress = 0x2110
+	p.WriteData(0x22)
+	p.VramAddress = 0x2220
+	p.WriteData(0x33)
+	p.VramAddress = 0x2330
+	p.WriteData(0x44)
+	p.VramAddress = 0x2338
+	p.WriteData(0x55)
+
+	verifyMirroredValue(p, 0x2000, 0x11, test)
+	verifyMirroredValue(p, 0x2800, 0x11, test)
+
+	verifyMirroredValue(p, 0x2110, 0x22, test)
+	verifyMirroredValue(p, 0x2910, 0x22, test)
+
+	verifyMirroredValue(p, 0x2220, 0x33, test)
+	verifyMirroredValue(p, 0x2A20, 0x33, test)
+
+	verifyMirroredValue(p, 0x2330, 0x44, test)
+	verifyMirroredValue(p, 0x2B30, 0x44, test)
+
+	verifyMirroredValue(p, 0x2338, 0x55, test)
+	verifyMirroredValue(p, 0x2B38, 0x55, test)
+
+	p.VramAddress = 0x2400
+	p.WriteData(0x11)
+	p.VramAddress = 0x2510
+	p.WriteData(0x22)
+	p.VramAddress = 0x2620
+	p.WriteData(0x33)
+	p.VramAddress = 0x2730
+	p.WriteData(0x44)
+	p.VramAddress = 0x2738
+	p.WriteData(0x55)
+
+	verifyMirroredValue(p, 0x2400, 0x11, test)
+	verifyMirroredValue(p, 0x2C00, 0x11, test)
+
+	verifyMirroredValue(p, 0x2510, 0x22, test)
+	verifyMirroredValue(p, 0x2D10, 0x22, test)
+
+	verifyMirroredValue(p, 0x2620, 0x33, test)
+	verifyMirroredValue(p, 0x2E20, 0x33, test)
+
+	verifyMirroredValue(p, 0x2730, 0x44, test)
+	verifyMirroredValue(p, 0x2F30, 0x44, test)
+
+	verifyMirroredValue(p, 0x2738, 0x55, test)
+	verifyMirroredValue(p, 0x2F38, 0x55, test)
+}
+
+func TestHorizontalToVertical(test *testing.T) {
+	p := new(ppu.Ppu)
+	p.Init()
+
+	rom := &Mmc1{
+		Ppu:          p,
+		RomBanks:     make([][]byte, 16),
+		VromBanks:    make([][]byte, 16),
+		PrgBankCount: 8,
+		ChrRomCount:  8,
+		Battery:      false,
+		Data:         make([]byte, 32),
+		PrgSwapBank:  BankLower,
+	}
+
+	p.Nametables.SetMirroring(ppu.MirroringVertical)
+
+	if p.Nametables.Mirroring != ppu.MirroringVertical {
+		test.Errorf("Mirroring was not vertical")
+	}
+
+	// Setup Vertical mirroring
+	rom.Write(0x8000, 0x1)
+	rom.Write(0x8000, 0x1)
+	rom.Write(0x8000, 0x0)
+	rom.Write(0x8000, 0x0)
+	rom.Write(0x8000, 0x0)
+
+	if p.Nametables.Mirroring != ppu.MirroringHorizontal {
+		test.Errorf("Mirroring was not horizontal")
+	}
+
+	p.VramAddress = 0x2000
+	p.WriteData(0x11)
+	p.VramAddress = 0x2110
+	p.WriteData(0x22)
+	p.VramAddress = 0x2220
+	p.WriteData(0x33)
+	p.VramAddress = 0x2330
+	p.WriteData(0x44)
+	p.VramAddress = 0x2338
+	p.WriteData(0x55)
+
+	verifyMirroredValue(p, 0x2000, 0x11, test)
+	verifyMirroredValue(p, 0x2400, 0x11, test)
+
+	verifyMirroredValue(p, 0x2110, 0x22, test)
+	verifyMirroredValue(p, 0x2510, 0x22, test)
+
+	verifyMirroredValue(p, 0x2220, 0x33, test)
+	verifyMirroredValue(p, 0x2620, 0x33, test)
+
+	verifyMirroredValue(p, 0x2330, 0x44, test)
+	verifyMirroredValue(p, 0x2730, 0x44, test)
+
+	verifyMirroredValue(p, 0x2338, 0x55, test)
+	verifyMirroredValue(p, 0x2738, 0x55, test)
+
+	p.VramAddress = 0x2800
+	p.WriteData(0x11)
+	p.VramAddress = 0x2910
+	p.WriteData(0x22)
+	p.VramAddress = 0x2A20
+	p.WriteData(0x33)
+	p.VramAddress = 0x2B30
+	p.WriteData(0x44)
+	p.VramAddress = 0x2B38
+	p.WriteData(0x55)
+
+	verifyMirroredValue(p, 0x2800, 0x11, test)
+	verifyMirroredValue(p, 0x2C00, 0x11, test)
+
+	verifyMirroredValue(p, 0x2910, 0x22, test)
+	verifyMirroredValue(p, 0x2D10, 0x22, test)
+
+	verifyMirroredValue(p, 0x2A20, 0x33, test)
+	verifyMirroredValue(p, 0x2E20, 0x33, test)
+
+	verifyMirroredValue(p, 0x2B30, 0x44, test)
+	verifyMirroredValue(p, 0x2F30, 0x44, test)
+
+	verifyMirroredValue(p, 0x2B38, 0x55, test)
+	verifyMirroredValue(p, 0x2F38, 0x55, test)
+}
+
+func TestConsecutiveWritesAreIgnored(test *testing.T) {
+	p := new(ppu.Ppu)
+	p.Init()
+
+	c := new(cpu.Cpu)
+
+	rom := &Mmc1{
+		Cpu:          c,
+		Ppu:          p,
+		RomBanks:     make([][]byte, 16),
+		VromBanks:    make([][]byte, 16),
+		PrgBankCount: 8,
+		ChrRomCount:  8,
+		Data:         make([]byte, 32),
+		PrgSwapBank:  BankLower,
+	}
+
+	p.Nametables.SetMirroring(ppu.MirroringHorizontal)
+
+	// Setup Vertical mirroring. The write on cycle 11 is the second
+	// write of a read-modify-write and is dropped.
+	writes := []struct {
+		cycle uint64
+		v     byte
+	}{
+		{10, 0x0}, {11, 0x0}, {20, 0x1}, {30, 0x0}, {40, 0x0}, {50, 0x0},
+	}
+
+	for _, w := range writes {
+		c.Cycles = w.cycle
+		rom.Write(0x8000, w.v)
+	}
+
+	if p.Nametables.Mirroring != ppu.MirroringVertical {
+		test.Errorf("Mirroring was not vertical")
+	}
+}
@@ -1,7 +1,8 @@
-package main
+package cartridge
 
 import (
-	"fmt"
+	"github.com/scottferg/Fergulator/cpu"
+	"github.com/scottferg/Fergulator/ppu"
 )
 
 const (
@@ -31,8 +32,14 @@ const (
 )
 
 type Mmc3 struct {
-	RomBanks  [][]Word
-	VromBanks [][]Word
+	Cpu *cpu.Cpu
+	Ppu *ppu.Ppu
+
+	PrgWindows
+	ChrWindows
+
+	RomBanks  [][]byte
+	VromBanks [][]byte
 
 	PrgBankCount int
 	ChrRomCount  int
@@ -52,6 +59,10 @@ type Mmc3 struct {
 
 func NewMmc3(r *Rom) *Mmc3 {
 	m := &Mmc3{
+		Cpu:          r.Cpu,
+		Ppu:          r.Ppu,
+		PrgWindows:   r.PrgWindows,
+		ChrWindows:   r.ChrWindows,
 		RomBanks:     r.RomBanks,
 		VromBanks:    r.VromBanks,
 		PrgBankCount: r.PrgBankCount,
@@ -70,27 +81,75 @@ func NewMmc3(r *Rom) *Mmc3 {
 }
 
 func (m *Mmc3) LoadRom() {
-	// The PRG banks are 8192 bytes in size, half the size of an 
-	// iNES PRG bank. If your emulator or copier handles PRG data 
-	// in 16384 byte chunks, you can think of the lower bit as 
+	// The PRG banks are 8192 bytes in size, half the size of an
+	// iNES PRG bank. If your emulator or copier handles PRG data
+	// in 16384 byte chunks, you can think of the lower bit as
 	// selecting the first or second half of the bank
 	//
 	// http://forums.nesdev.com/viewtopic.php?p=38182#p38182
 
-	// Write hardwired PRG banks (0xC000 and 0xE000) 
-	m.Write8kRamBank((m.PrgBankCount-1)*2, 0xC000)
-	m.Write8kRamBank(((m.PrgBankCount-1)*2)+1, 0xE000)
+	// Write hardwired PRG banks (0xC000 and 0xE000)
+	m.Map8kPrgBank((m.PrgBankCount-1)*2, 0xC000)
+	m.Map8kPrgBank(((m.PrgBankCount-1)*2)+1, 0xE000)
 
 	// Write swappable PRG banks (0x8000 and 0xA000)
-	m.Write8kRamBank(0, 0x8000)
-	m.Write8kRamBank(1, 0xA000)
+	m.Map8kPrgBank(0, 0x8000)
+	m.Map8kPrgBank(1, 0xA000)
 }
 
 func (m *Mmc3) BatteryBacked() bool {
 	return m.Battery
 }
 
-func (m *Mmc3) Write(v Word, a int) {
+type mmc3State struct {
+	Banks bankState
+
+	BankSelection   uint8
+	PrgBankMode     uint8
+	ChrA12Inversion uint8
+	AddressChanged  bool
+	IrqEnabled      bool
+	IrqLatchValue   int32
+	IrqCounter      int32
+	IrqPreset       int32
+	IrqPresetVbl    int32
+}
+
+func (m *Mmc3) SaveState() []byte {
+	s := mmc3State{
+		BankSelection:   uint8(m.BankSelection),
+		PrgBankMode:     uint8(m.PrgBankMode),
+		ChrA12Inversion: uint8(m.ChrA12Inversion),
+		AddressChanged:  m.AddressChanged,
+		IrqEnabled:      m.IrqEnabled,
+		IrqLatchValue:   int32(m.IrqLatchValue),
+		IrqCounter:      int32(m.IrqCounter),
+		IrqPreset:       int32(m.IrqPreset),
+		IrqPresetVbl:    int32(m.IrqPresetVbl),
+	}
+	s.Banks.save(&m.PrgWindows, &m.ChrWindows)
+
+	return encodeState(&s)
+}
+
+func (m *Mmc3) LoadState(state []byte) {
+	var s mmc3State
+	decodeState(state, &s)
+
+	s.Banks.restore(m.Ppu, &m.PrgWindows, &m.ChrWindows)
+
+	m.BankSelection = int(s.BankSelection)
+	m.PrgBankMode = int(s.PrgBankMode)
+	m.ChrA12Inversion = int(s.ChrA12Inversion)
+	m.AddressChanged = s.AddressChanged
+	m.IrqEnabled = s.IrqEnabled
+	m.IrqLatchValue = int(s.IrqLatchValue)
+	m.IrqCounter = int(s.IrqCounter)
+	m.IrqPreset = int(s.IrqPreset)
+	m.IrqPresetVbl = int(s.IrqPresetVbl)
+}
+
+func (m *Mmc3) Write(a uint16, v byte) {
 	switch m.RegisterNumber(a) {
 	case RegisterBankSelect:
 		m.BankSelect(int(v))
@@ -111,7 +170,7 @@ func (m *Mmc3) Write(v Word, a int) {
 	}
 }
 
-func (m *Mmc3) RegisterNumber(a int) int {
+func (m *Mmc3) RegisterNumber(a uint16) int {
 	switch {
 	case a >= 0x8000 && a <= 0x9FFF:
 		if a%2 == 0 {
@@ -158,10 +217,10 @@ func (m *Mmc3) BankData(v int) {
 		if m.AddressChanged {
 			if m.PrgBankMode == PrgBankSwapModeLow {
 				//fmt.Println("Changed address high")
-				m.Write8kRamBank((m.PrgBankCount-1)*2, 0xC000)
+				m.Map8kPrgBank((m.PrgBankCount-1)*2, 0xC000)
 			} else {
 				//fmt.Println("Changed address low")
-				m.Write8kRamBank((m.PrgBankCount-1)*2, 0x8000)
+				m.Map8kPrgBank((m.PrgBankCount-1)*2, 0x8000)
 			}
 
 			m.AddressChanged = false
@@ -177,12 +236,12 @@ func (m *Mmc3) BankData(v int) {
 		//fmt.Printf("2k @ 0x0000: ")
 		if m.ChrA12Inversion == ChrA12InversionModeLow {
 			//fmt.Printf("ModeLow CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x0000)
-			m.Write1kVramBank(v+1, 0x0400)
+			m.Map1kChrBank(v, 0x0000)
+			m.Map1kChrBank(v+1, 0x0400)
 		} else {
 			//fmt.Printf("ModeHigh CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x1000)
-			m.Write1kVramBank(v+1, 0x1400)
+			m.Map1kChrBank(v, 0x1000)
+			m.Map1kChrBank(v+1, 0x1400)
 		}
 	case ChrBank2k0800:
 		if m.ChrRomCount == 0 {
@@ -192,12 +251,12 @@ func (m *Mmc3) BankData(v int) {
 		//fmt.Printf("2k @ 0x0800: ")
 		if m.ChrA12Inversion == ChrA12InversionModeLow {
 			//fmt.Printf("ModeLow CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x0800)
-			m.Write1kVramBank(v+1, 0x0C00)
+			m.Map1kChrBank(v, 0x0800)
+			m.Map1kChrBank(v+1, 0x0C00)
 		} else {
 			//fmt.Printf("ModeHigh CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x1800)
-			m.Write1kVramBank(v+1, 0x1C00)
+			m.Map1kChrBank(v, 0x1800)
+			m.Map1kChrBank(v+1, 0x1C00)
 		}
 	case ChrBank1k1000:
 		if m.ChrRomCount == 0 {
@@ -207,10 +266,10 @@ func (m *Mmc3) BankData(v int) {
 		//fmt.Printf("1k @ 0x1000: ")
 		if m.ChrA12Inversion == ChrA12InversionModeLow {
 			//fmt.Printf("ModeLow CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x1000)
+			m.Map1kChrBank(v, 0x1000)
 		} else {
 			//fmt.Printf("ModeHigh CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x0000)
+			m.Map1kChrBank(v, 0x0000)
 		}
 	case ChrBank1k1400:
 		if m.ChrRomCount == 0 {
@@ -220,10 +279,10 @@ func (m *Mmc3) BankData(v int) {
 		//fmt.Printf("1k @ 0x1400: ")
 		if m.ChrA12Inversion == ChrA12InversionModeLow {
 			//fmt.Printf("ModeLow CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x1400)
+			m.Map1kChrBank(v, 0x1400)
 		} else {
 			//fmt.Printf("ModeHigh CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x0400)
+			m.Map1kChrBank(v, 0x0400)
 		}
 	case ChrBank1k1800:
 		if m.ChrRomCount == 0 {
@@ -233,10 +292,10 @@ func (m *Mmc3) BankData(v int) {
 		//fmt.Printf("1k @ 0x1800: ")
 		if m.ChrA12Inversion == ChrA12InversionModeLow {
 			//fmt.Printf("ModeLow CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x1800)
+			m.Map1kChrBank(v, 0x1800)
 		} else {
 			//fmt.Printf("ModeHigh CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x0800)
+			m.Map1kChrBank(v, 0x0800)
 		}
 	case ChrBank1k1C00:
 		if m.ChrRomCount == 0 {
@@ -246,24 +305,24 @@ func (m *Mmc3) BankData(v int) {
 		//fmt.Printf("1k @ 0x1C00: ")
 		if m.ChrA12Inversion == ChrA12InversionModeLow {
 			//fmt.Printf("ModeLow CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x1C00)
+			m.Map1kChrBank(v, 0x1C00)
 		} else {
 			//fmt.Printf("ModeHigh CHR on bank -> %d\n", v)
-			m.Write1kVramBank(v, 0x0C00)
+			m.Map1kChrBank(v, 0x0C00)
 		}
 	case PrgBank8k8000:
 		loadHardBanks()
 
 		if m.PrgBankMode == PrgBankSwapModeLow {
 			// fmt.Printf("0x%X: Low mode PRG switch on bank -> %d\n", ProgramCounter, v)
-			m.Write8kRamBank(v, 0x8000)
+			m.Map8kPrgBank(v, 0x8000)
 		} else {
 			// fmt.Printf("0x%X: High mode PRG switch on bank -> %d\n", ProgramCounter, v)
-			m.Write8kRamBank(v, 0xC000)
+			m.Map8kPrgBank(v, 0xC000)
 		}
 	case PrgBank8kA000:
 		// fmt.Printf("0x%X: 8k 0xA000 PRG switch on bank -> %d\n", ProgramCounter, v)
-		m.Write8kRamBank(v, 0xA000)
+		m.Map8kPrgBank(v, 0xA000)
 
 		loadHardBanks()
 	}
@@ -272,15 +331,14 @@ func (m *Mmc3) BankData(v int) {
 func (m *Mmc3) SetMirroring(v int) {
 	switch v & 0x1 {
 	case 0x0:
-		ppu.Nametables.SetMirroring(MirroringVertical)
+		m.Ppu.Nametables.SetMirroring(ppu.MirroringVertical)
 	case 0x1:
-		ppu.Nametables.SetMirroring(MirroringHorizontal)
+		m.Ppu.Nametables.SetMirroring(ppu.MirroringHorizontal)
 	}
 }
 
 func (m *Mmc3) RamProtection(v int) {
-    // TODO: WhAT IS THIS I DON'T EVEN
-	fmt.Println("RamProtection register")
+	// PRG-RAM is always enabled and writable
 }
 
 func (m *Mmc3) IrqLatch(v int) {
@@ -290,7 +348,7 @@ func (m *Mmc3) IrqLatch(v int) {
 
 func (m *Mmc3) IrqReload(v int) {
 	// $C001
-	if ppu.Scanline < 240 {
+	if m.Ppu.Scanline < 240 {
 		m.IrqCounter |= 0x80
 		m.IrqPreset = 0xFF
 	} else {
@@ -301,8 +359,9 @@ func (m *Mmc3) IrqReload(v int) {
 }
 
 func (m *Mmc3) IrqDisable(v int) {
-	// $E001
+	// $E000, also acknowledges any pending IRQ
 	m.IrqEnabled = false
+	m.Cpu.AcknowledgeIrq(cpu.IrqMapper)
 }
 
 func (m *Mmc3) IrqEnable(v int) {
@@ -310,28 +369,16 @@ func (m *Mmc3) IrqEnable(v int) {
 	m.IrqEnabled = true
 }
 
-func (m *Mmc3) Write8kRamBank(bank, dest int) {
-	b := (bank / 2) % m.PrgBankCount
-	offset := (bank % 2) * 0x2000
-
-	//fmt.Printf("Updating bank at: 0x%X\n", dest)
-	//fmt.Printf("Upper 8k offset: %d\n", offset)
-
-	WriteOffsetRamBank(m.RomBanks, b, dest, Size8k, offset)
+func (m *Mmc3) Map8kPrgBank(bank, dest int) {
+	m.mapPrg(bank, dest, Size8k)
 }
 
-func (m *Mmc3) Write1kVramBank(bank, dest int) {
-	b := (bank / 4)
-	offset := (bank % 4) * 0x400
-
-	//fmt.Printf("Updating bank: %d\n", b)
-	//fmt.Printf("Upper 1k offset: %d\n", offset)
-
-	WriteOffsetVramBank(m.VromBanks, b, dest, Size1k, offset)
+func (m *Mmc3) Map1kChrBank(bank, dest int) {
+	m.mapChr(m.Ppu, bank, dest, Size1k)
 }
 
 func (m *Mmc3) Hook() {
-	if (ppu.Scanline > -1 && ppu.Scanline < 240) && (ppu.ShowBackground || ppu.ShowSprites) {
+	if (m.Ppu.Scanline > -1 && m.Ppu.Scanline < 240) && (m.Ppu.ShowBackground || m.Ppu.ShowSprites) {
 		if m.IrqPresetVbl > 0x0 {
 			m.IrqCounter = m.IrqLatchValue
 			m.IrqPresetVbl = 0x0
@@ -346,7 +393,7 @@ func (m *Mmc3) Hook() {
 
 		if m.IrqCounter == 0 {
 			if m.IrqEnabled {
-				cpu.RequestInterrupt(InterruptIrq)
+				m.Cpu.AssertIrq(cpu.IrqMapper)
 			}
 		}
 	}
@@ -0,0 +1,468 @@
+// Package cartridge loads iNES images and implements the memory
+// mappers found on NES cartridge boards.
+package cartridge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/scottferg/Fergulator/cpu"
+	"github.com/scottferg/Fergulator/ppu"
+)
+
+const (
+	Size1k  = 0x0400
+	Size4k  = 0x1000
+	Size8k  = 0x2000
+	Size16k = 0x4000
+	Size32k = 0x8000
+)
+
+type Mapper interface {
+	Read(a uint16) byte
+	Write(a uint16, v byte)
+	BatteryBacked() bool
+	Hook()
+
+	// PrgBank returns the 8k PRG bank mapped at CPU address a,
+	// or -1 if a isn't in PRG-ROM
+	PrgBank(a int) int
+
+	// SaveState returns the mapper's registers and the banks it has
+	// mapped, StateSize bytes of them. LoadState puts them back.
+	SaveState() []byte
+	LoadState(state []byte)
+}
+
+// Size of every mapper's save state
+const StateSize = 64
+
+// HeaderError is returned when an image doesn't start with a
+// usable iNES header
+type HeaderError struct {
+	ErrorText string
+}
+
+func (e HeaderError) Error() string {
+	return "Invalid ROM file: " + e.ErrorText
+}
+
+// TruncatedRomError is returned when an image is shorter than the
+// bank counts in its header say it should be
+type TruncatedRomError struct {
+	Expected int
+	Actual   int
+}
+
+func (e TruncatedRomError) Error() string {
+	return fmt.Sprintf("Truncated ROM file: expected %d bytes, got %d", e.Expected, e.Actual)
+}
+
+// UnsupportedMapperError is returned for boards we have no mapper for
+type UnsupportedMapperError struct {
+	Mapper int
+}
+
+func (e UnsupportedMapperError) Error() string {
+	return fmt.Sprintf("Unsupported memory mapper: 0x%X", e.Mapper)
+}
+
+// PrgWindows is the page table for $8000-$FFFF. Each 8k window
+// points straight into PRG-ROM, so switching banks doesn't copy
+// anything and the CPU has no way to write to ROM.
+type PrgWindows struct {
+	prg   [][]byte
+	pages [4][]byte
+	banks [4]int
+}
+
+// Maps size bytes of PRG-ROM, starting at 8k bank number bank, to
+// the CPU address dest. Bank numbers wrap like the mask ROM's
+// address lines do.
+func (w *PrgWindows) mapPrg(bank, dest, size int) {
+	for i := 0; i < size/Size8k; i++ {
+		b := (bank + i) % len(w.prg)
+
+		w.pages[(dest-0x8000)/Size8k+i] = w.prg[b]
+		w.banks[(dest-0x8000)/Size8k+i] = b
+	}
+}
+
+func (w *PrgWindows) Read(a uint16) byte {
+	return w.pages[a>>13&0x3][a&0x1FFF]
+}
+
+func (w *PrgWindows) PrgBank(a int) int {
+	if a < 0x8000 || a > 0xFFFF {
+		return -1
+	}
+
+	return w.banks[(a-0x8000)/Size8k]
+}
+
+// ChrWindows is the page table for the PPU's pattern tables. It points
+// each 1k page at CHR-ROM and keeps the bank number, so the mapping can
+// be saved. Boards with CHR-RAM leave every page on the PPU's ChrRam.
+type ChrWindows struct {
+	chr      [][]byte
+	chrBanks [8]int
+}
+
+// Maps size bytes of CHR-ROM, starting at 1k bank number bank, to the
+// PPU address dest
+func (w *ChrWindows) mapChr(p *ppu.Ppu, bank, dest, size int) {
+	// Carts without CHR-ROM keep their CHR-RAM mapped
+	if len(w.chr) == 0 {
+		return
+	}
+
+	for i := 0; i < size/Size1k; i++ {
+		b := (bank + i) % len(w.chr)
+
+		p.MapChr(dest+i*Size1k, w.chr[b], false)
+		w.chrBanks[dest/Size1k+i] = b
+	}
+}
+
+// The banks every mapper keeps in its save state, -1 for a page of
+// CHR-RAM
+type bankState struct {
+	Prg [4]int16
+	Chr [8]int16
+}
+
+func (s *bankState) save(prg *PrgWindows, chr *ChrWindows) {
+	for i, b := range prg.banks {
+		s.Prg[i] = int16(b)
+	}
+
+	for i, b := range chr.chrBanks {
+		s.Chr[i] = int16(b)
+	}
+}
+
+func (s *bankState) restore(p *ppu.Ppu, prg *PrgWindows, chr *ChrWindows) {
+	for i, b := range s.Prg {
+		prg.mapPrg(int(b), 0x8000+i*Size8k, Size8k)
+	}
+
+	for i, b := range s.Chr {
+		if b < 0 {
+			p.MapChr(i*Size1k, p.ChrRam[i*Size1k:(i+1)*Size1k], true)
+			chr.chrBanks[i] = -1
+		} else {
+			chr.mapChr(p, int(b), i*Size1k, Size1k)
+		}
+	}
+}
+
+// Packs a mapper's state struct into StateSize bytes
+func encodeState(s interface{}) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, s)
+
+	state := make([]byte, StateSize)
+	copy(state, buf.Bytes())
+
+	return state
+}
+
+func decodeState(state []byte, s interface{}) {
+	binary.Read(bytes.NewReader(state), binary.BigEndian, s)
+}
+
+// Nrom
+type Rom struct {
+	Cpu *cpu.Cpu
+	Ppu *ppu.Ppu
+
+	PrgWindows
+	ChrWindows
+
+	RomBanks  [][]byte
+	VromBanks [][]byte
+
+	PrgBankCount int
+	ChrRomCount  int
+	Battery      bool
+	Data         []byte
+}
+
+type Unrom Rom
+type Cnrom Rom
+
+func (m *Rom) Write(a uint16, v byte) {
+	// Nothing to do
+}
+
+func (m *Rom) Hook() {
+	// No hooks
+}
+
+func (m *Rom) BatteryBacked() bool {
+	return m.Battery
+}
+
+func (m *Rom) SaveState() []byte {
+	var s bankState
+	s.save(&m.PrgWindows, &m.ChrWindows)
+
+	return encodeState(&s)
+}
+
+func (m *Rom) LoadState(state []byte) {
+	var s bankState
+	decodeState(state, &s)
+
+	s.restore(m.Ppu, &m.PrgWindows, &m.ChrWindows)
+}
+
+func (m *Unrom) Write(a uint16, v byte) {
+	m.mapPrg(int(v&0x7)*2, 0x8000, Size16k)
+}
+
+func (m *Unrom) Hook() {
+	// No hooks
+}
+
+func (m *Unrom) BatteryBacked() bool {
+	return m.Battery
+}
+
+func (m *Unrom) SaveState() []byte {
+	return (*Rom)(m).SaveState()
+}
+
+func (m *Unrom) LoadState(state []byte) {
+	(*Rom)(m).LoadState(state)
+}
+
+func (m *Cnrom) Write(a uint16, v byte) {
+	m.mapChr(m.Ppu, int(v&0x3)*8, 0x0000, Size8k)
+}
+
+func (m *Cnrom) Hook() {
+	// No hooks
+}
+
+func (m *Cnrom) BatteryBacked() bool {
+	return m.Battery
+}
+
+func (m *Cnrom) SaveState() []byte {
+	return (*Rom)(m).SaveState()
+}
+
+func (m *Cnrom) LoadState(state []byte) {
+	(*Rom)(m).LoadState(state)
+}
+
+// Header is what the 16 byte iNES header says about a ROM image
+type Header struct {
+	PrgBankCount int
+	ChrRomCount  int
+	Mapper       int
+	Vertical     bool
+	Battery      bool
+}
+
+// Boards by mapper number. $40-$42 are 0-2 with junk in byte 7 left
+// by old dumping tools.
+var mapperNames = map[int]string{
+	0x00: "NROM",
+	0x40: "NROM",
+	0x41: "NROM",
+	0x01: "MMC1",
+	0x02: "UNROM",
+	0x42: "UNROM",
+	0x03: "CNROM",
+	0x04: "MMC3",
+}
+
+// ReadHeader checks an iNES image is complete and reads its header
+func ReadHeader(rom []byte) (h Header, e error) {
+	if len(rom) < 16 {
+		return h, HeaderError{ErrorText: "file is too short"}
+	}
+
+	if string(rom[0:3]) != "NES" || rom[3] != 0x1a {
+		return h, HeaderError{ErrorText: "missing NES<EOF> signature"}
+	}
+
+	h.PrgBankCount = int(rom[4])
+	h.ChrRomCount = int(rom[5])
+
+	if h.PrgBankCount == 0 {
+		return h, HeaderError{ErrorText: "no PRG-ROM banks"}
+	}
+
+	if expected := 16 + h.PrgBankCount*Size16k + h.ChrRomCount*Size8k; len(rom) < expected {
+		return h, TruncatedRomError{Expected: expected, Actual: len(rom)}
+	}
+
+	h.Mapper = int(rom[6]>>4 | rom[7]&0xF0)
+	h.Vertical = rom[6]&0x1 == 0x1
+	h.Battery = rom[6]&0x2 == 0x2
+
+	return h, nil
+}
+
+// String describes the image the way a header dump would
+func (h Header) String() string {
+	mirroring := "Horizontal"
+	if h.Vertical {
+		mirroring = "Vertical"
+	}
+
+	name, ok := mapperNames[h.Mapper]
+	if !ok {
+		name = "Unsupported"
+	}
+
+	return fmt.Sprintf("PRG-ROM banks: %d\nCHR-ROM banks: %d\nMirroring: %s\nMapper: %s\n",
+		h.PrgBankCount, h.ChrRomCount, mirroring, name)
+}
+
+// LoadRom parses an iNES image and wires the matching mapper to the
+// CPU and the PPU. The mapper answers CPU reads and writes to
+// $8000-$FFFF through Read and Write.
+func LoadRom(rom []byte, c *cpu.Cpu, p *ppu.Ppu) (m Mapper, e error) {
+	r := &Rom{Cpu: c, Ppu: p}
+
+	h, e := ReadHeader(rom)
+	if e != nil {
+		return m, e
+	}
+
+	r.PrgBankCount = h.PrgBankCount
+	r.ChrRomCount = h.ChrRomCount
+	r.Battery = h.Battery
+
+	if h.Vertical {
+		p.Nametables.SetMirroring(ppu.MirroringVertical)
+	} else {
+		p.Nametables.SetMirroring(ppu.MirroringHorizontal)
+	}
+
+	r.Data = rom[16:]
+
+	r.RomBanks = make([][]byte, r.PrgBankCount)
+	for i := 0; i < r.PrgBankCount; i++ {
+		// Move 16kb chunk to 16kb bank
+		bank := make([]byte, 0x4000)
+		for x := 0; x < 0x4000; x++ {
+			bank[x] = byte(r.Data[(0x4000*i)+x])
+		}
+
+		r.RomBanks[i] = bank
+		r.prg = append(r.prg, bank[:Size8k], bank[Size8k:])
+	}
+
+	// Everything after PRG-ROM
+	chrRom := r.Data[0x4000*len(r.RomBanks):]
+
+	r.VromBanks = make([][]byte, r.ChrRomCount*2)
+	for i := 0; i < r.ChrRomCount*2; i++ {
+		// Move 16kb chunk to 16kb bank
+		bank := make([]byte, 0x1000)
+		for x := 0; x < 0x1000; x++ {
+			bank[x] = byte(chrRom[(0x1000*i)+x])
+		}
+
+		r.VromBanks[i] = bank
+
+		for x := 0; x < 0x1000; x += Size1k {
+			r.chr = append(r.chr, bank[x:x+Size1k])
+		}
+	}
+
+	for i := range r.chrBanks {
+		r.chrBanks[i] = -1
+	}
+
+	// Map the first ROM bank
+	r.mapPrg(0, 0x8000, Size16k)
+
+	if r.PrgBankCount > 1 {
+		// and the last ROM bank
+		r.mapPrg((r.PrgBankCount-1)*2, 0xC000, Size16k)
+	} else {
+		// Or mirror the first ROM bank into the upper region
+		r.mapPrg(0, 0xC000, Size16k)
+	}
+
+	// If we have CHR-ROM, map the first two banks
+	// into pattern tables 0x0000-0x1FFF
+	if r.ChrRomCount > 0 {
+		if r.ChrRomCount == 1 {
+			r.mapChr(r.Ppu, 0, 0x0000, Size8k)
+		} else {
+			r.mapChr(r.Ppu, 0, 0x0000, Size4k)
+			r.mapChr(r.Ppu, (len(r.VromBanks)-1)*4, 0x1000, Size4k)
+		}
+	}
+
+	// Check mapper, get the proper type
+	switch h.Mapper {
+	case 0x00:
+		fallthrough
+	case 0x40:
+		fallthrough
+	case 0x41:
+		// NROM
+		return r, nil
+	case 0x01:
+		// MMC1
+		m = &Mmc1{
+			Cpu:          r.Cpu,
+			Ppu:          r.Ppu,
+			PrgWindows:   r.PrgWindows,
+			ChrWindows:   r.ChrWindows,
+			RomBanks:     r.RomBanks,
+			VromBanks:    r.VromBanks,
+			PrgBankCount: r.PrgBankCount,
+			ChrRomCount:  r.ChrRomCount,
+			Battery:      r.Battery,
+			Data:         r.Data,
+			PrgSwapBank:  BankLower,
+		}
+	case 0x42:
+		fallthrough
+	case 0x02:
+		// Unrom
+		m = &Unrom{
+			Cpu:          r.Cpu,
+			Ppu:          r.Ppu,
+			PrgWindows:   r.PrgWindows,
+			ChrWindows:   r.ChrWindows,
+			RomBanks:     r.RomBanks,
+			VromBanks:    r.VromBanks,
+			PrgBankCount: r.PrgBankCount,
+			ChrRomCount:  r.ChrRomCount,
+			Battery:      r.Battery,
+			Data:         r.Data,
+		}
+	case 0x03:
+		// Cnrom
+		m = &Cnrom{
+			Cpu:          r.Cpu,
+			Ppu:          r.Ppu,
+			PrgWindows:   r.PrgWindows,
+			ChrWindows:   r.ChrWindows,
+			RomBanks:     r.RomBanks,
+			VromBanks:    r.VromBanks,
+			PrgBankCount: r.PrgBankCount,
+			ChrRomCount:  r.ChrRomCount,
+			Battery:      r.Battery,
+			Data:         r.Data,
+		}
+	case 0x04:
+		// MMC3
+		m = NewMmc3(r)
+	default:
+		// Unsupported
+		return m, UnsupportedMapperError{Mapper: h.Mapper}
+	}
+
+	return
+}
@@ -0,0 +1,270 @@
+package cartridge
+
+import (
+	"github.com/scottferg/Fergulator/ppu"
+	"strings"
+	"testing"
+)
+
+func testImage(prgBanks, chrBanks, mapper byte) []byte {
+	header := []byte{'N', 'E', 'S', 0x1a, prgBanks, chrBanks, mapper << 4, mapper & 0xF0}
+	image := make([]byte, 16+int(prgBanks)*Size16k+int(chrBanks)*Size8k)
+	copy(image, header)
+
+	return image
+}
+
+func loadTestImage(image []byte) (Mapper, error) {
+	p := new(ppu.Ppu)
+	p.Init()
+
+	return LoadRom(image, nil, p)
+}
+
+func TestBadHeader(test *testing.T) {
+	if _, err := loadTestImage([]byte("NES")); err == nil {
+		test.Errorf("Short header loaded")
+	} else if _, ok := err.(HeaderError); !ok {
+		test.Errorf("Short header returned %T, expected HeaderError", err)
+	}
+
+	image := testImage(1, 1, 0)
+	image[3] = 0
+
+	if _, err := loadTestImage(image); err == nil {
+		test.Errorf("Bad signature loaded")
+	} else if _, ok := err.(HeaderError); !ok {
+		test.Errorf("Bad signature returned %T, expected HeaderError", err)
+	}
+}
+
+func TestTruncatedRom(test *testing.T) {
+	image := testImage(2, 1, 0)
+	image = image[:len(image)-1]
+
+	_, err := loadTestImage(image)
+
+	e, ok := err.(TruncatedRomError)
+	if !ok {
+		test.Fatalf("Truncated ROM returned %v, expected TruncatedRomError", err)
+	}
+
+	if e.Expected != len(image)+1 || e.Actual != len(image) {
+		test.Errorf("Truncated ROM reported %d/%d bytes", e.Actual, e.Expected)
+	}
+}
+
+func TestUnsupportedMapper(test *testing.T) {
+	_, err := loadTestImage(testImage(1, 1, 0x05))
+
+	if e, ok := err.(UnsupportedMapperError); !ok || e.Mapper != 0x05 {
+		test.Errorf("Mapper 5 returned %v, expected UnsupportedMapperError", err)
+	}
+}
+
+func TestPrgBank(test *testing.T) {
+	m, err := loadTestImage(testImage(4, 1, 0x02))
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	m.Write(0x8000, 0x2)
+
+	if b := m.PrgBank(0x8123); b != 4 {
+		test.Errorf("Bank at 0x8123 was %d, expected 4", b)
+	}
+
+	if b := m.PrgBank(0xE000); b != 7 {
+		test.Errorf("Bank at 0xE000 was %d, expected 7", b)
+	}
+
+	if b := m.PrgBank(0x0200); b != -1 {
+		test.Errorf("Bank at 0x0200 was %d, expected -1", b)
+	}
+}
+
+func TestPrgRead(test *testing.T) {
+	image := testImage(1, 1, 0)
+	image[16] = 0x4C
+	image[16+0x3FFC] = 0x00
+	image[16+0x3FFD] = 0x80
+
+	m, err := loadTestImage(image)
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	// A single 16k bank is mirrored into both halves
+	for _, a := range []uint16{0x8000, 0xC000} {
+		if v := m.Read(a); v != 0x4C {
+			test.Errorf("0x%X was 0x%X, expected 0x4C", a, v)
+		}
+	}
+
+	if v := m.Read(0xFFFD); v != 0x80 {
+		test.Errorf("0xFFFD was 0x%X, expected 0x80", v)
+	}
+}
+
+// Tags the first byte of every 8k PRG bank and 1k CHR bank with
+// its bank number
+func taggedImage(prgBanks, chrBanks, mapper byte) []byte {
+	image := testImage(prgBanks, chrBanks, mapper)
+
+	prg := image[16 : 16+int(prgBanks)*Size16k]
+	for i := 0; i < len(prg); i += Size8k {
+		prg[i] = byte(i / Size8k)
+	}
+
+	chr := image[16+len(prg):]
+	for i := 0; i < len(chr); i += Size1k {
+		chr[i] = byte(i / Size1k)
+	}
+
+	return image
+}
+
+func readChr(p *ppu.Ppu, a int) byte {
+	p.WriteAddress(byte(a >> 8))
+	p.WriteAddress(byte(a))
+
+	// Pattern table reads are buffered
+	p.ReadData()
+	return p.ReadData()
+}
+
+func writeChr(p *ppu.Ppu, a int, v byte) {
+	p.WriteAddress(byte(a >> 8))
+	p.WriteAddress(byte(a))
+	p.WriteData(v)
+}
+
+func TestMmc3BankSwitching(test *testing.T) {
+	p := new(ppu.Ppu)
+	p.Init()
+
+	m, err := LoadRom(taggedImage(4, 8, 0x04), nil, p)
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	// R6, 8k PRG at $8000
+	m.Write(0x8000, 0x06)
+	m.Write(0x8001, 0x05)
+
+	if v := m.Read(0x8000); v != 5 {
+		test.Errorf("0x8000 held bank %d, expected 5", v)
+	}
+
+	// The last bank stays fixed at $E000
+	if v := m.Read(0xE000); v != 7 {
+		test.Errorf("0xE000 held bank %d, expected 7", v)
+	}
+
+	// R2, 1k CHR at $1000
+	m.Write(0x8000, 0x02)
+	m.Write(0x8001, 37)
+
+	if v := readChr(p, 0x1000); v != 37 {
+		test.Errorf("0x1000 held CHR bank %d, expected 37", v)
+	}
+}
+
+func TestChrRomIsReadOnly(test *testing.T) {
+	p := new(ppu.Ppu)
+	p.Init()
+
+	if _, err := LoadRom(taggedImage(1, 1, 0), nil, p); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	writeChr(p, 0x0400, 0xFF)
+
+	if v := readChr(p, 0x0400); v != 1 {
+		test.Errorf("CHR-ROM was overwritten with 0x%X", v)
+	}
+}
+
+func TestChrRamIsWritable(test *testing.T) {
+	p := new(ppu.Ppu)
+	p.Init()
+
+	if _, err := LoadRom(taggedImage(1, 0, 0), nil, p); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	writeChr(p, 0x1234, 0x99)
+
+	if v := readChr(p, 0x1234); v != 0x99 {
+		test.Errorf("CHR-RAM read back 0x%X, expected 0x99", v)
+	}
+}
+
+func TestCnromSaveState(test *testing.T) {
+	p := new(ppu.Ppu)
+	p.Init()
+
+	m, err := LoadRom(taggedImage(2, 4, 0x03), nil, p)
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	m.Write(0x8000, 0x02)
+	state := m.SaveState()
+
+	p = new(ppu.Ppu)
+	p.Init()
+
+	if m, err = LoadRom(taggedImage(2, 4, 0x03), nil, p); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	m.LoadState(state)
+
+	if v := readChr(p, 0x1C00); v != 23 {
+		test.Errorf("0x1C00 held CHR bank %d, expected 23", v)
+	}
+}
+
+func TestCnromWithoutChrRom(test *testing.T) {
+	p := new(ppu.Ppu)
+	p.Init()
+
+	m, err := LoadRom(testImage(1, 0, 0x03), nil, p)
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	writeChr(p, 0x0123, 0x45)
+	m.Write(0x8000, 0x01)
+
+	if v := readChr(p, 0x0123); v != 0x45 {
+		test.Errorf("0x0123 held 0x%X after a bank switch, expected CHR-RAM's 0x45", v)
+	}
+
+	// A malformed state can name CHR-ROM banks the cart doesn't have
+	state := m.SaveState()
+	for i := 8; i < 24; i++ {
+		state[i] = 0
+	}
+	m.LoadState(state)
+
+	if v := readChr(p, 0x0123); v != 0x45 {
+		test.Errorf("0x0123 held 0x%X after loading state, expected CHR-RAM's 0x45", v)
+	}
+}
+
+func TestReadHeader(test *testing.T) {
+	h, err := ReadHeader(testImage(2, 4, 0x04))
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	if h.PrgBankCount != 2 || h.ChrRomCount != 4 || h.Mapper != 4 {
+		test.Errorf("Read %+v", h)
+	}
+
+	if s := h.String(); !strings.Contains(s, "Mapper: MMC3") {
+		test.Errorf("Header summary was %q", s)
+	}
+}
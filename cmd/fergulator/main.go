@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/scottferg/Fergulator/cartridge"
+	"github.com/scottferg/Fergulator/frontend"
+	"github.com/scottferg/Fergulator/nes"
+	"github.com/scottferg/Fergulator/ppu"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	cycle         = "559ns"
+	clockspeed, _ = time.ParseDuration(cycle)
+
+	video frontend.Video
+
+	gamename       string
+	batteryRamFile string
+
+	traceFile   = flag.String("trace", "", "log every instruction the CPU runs to this file, T pauses and resumes it")
+	traceFormat = flag.String("trace-format", "nestest", "layout of the trace log: nestest, fceux or mesen")
+	traceRange  = flag.String("trace-pc", "", "only log instructions at addresses in this range, such as C000-C7FF")
+	traceBank   = flag.Int("trace-bank", -1, "only log instructions in this 8k PRG bank")
+
+	palette    = flag.String("palette", "", "colors to draw in: 2c02, 2c03, fceux, nestopia, ntsc or a .pal file")
+	hue        = flag.Float64("hue", ppu.DefaultNtsc.Hue, "hue of the ntsc palette, in degrees")
+	saturation = flag.Float64("saturation", ppu.DefaultNtsc.Saturation, "saturation of the ntsc palette")
+	contrast   = flag.Float64("contrast", ppu.DefaultNtsc.Contrast, "contrast of the ntsc palette")
+	brightness = flag.Float64("brightness", ppu.DefaultNtsc.Brightness, "brightness of the ntsc palette")
+)
+
+// Picks the palette for the -palette flag. ntsc generates one with the
+// settings from the other flags, anything with a .pal extension is
+// loaded from that file.
+func loadPalette() ([]uint32, error) {
+	switch {
+	case *palette == "ntsc":
+		return ppu.GeneratePalette(ppu.NtscSettings{
+			Hue:        *hue,
+			Saturation: *saturation,
+			Contrast:   *contrast,
+			Brightness: *brightness,
+		}), nil
+	case strings.HasSuffix(strings.ToLower(*palette), ".pal"):
+		return ppu.LoadPalette(*palette)
+	}
+
+	return ppu.BuiltinPalette(*palette)
+}
+
+// Attaches a tracer for the -trace flags. The returned function
+// flushes the log and closes it.
+func openTrace(console *nes.Console) (func(), error) {
+	format, err := nes.ParseTraceFormat(*traceFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(*traceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tracer := nes.NewTracer(f, format)
+	tracer.Bank = *traceBank
+
+	if *traceRange != "" {
+		if _, err = fmt.Sscanf(*traceRange, "%X-%X", &tracer.Start, &tracer.End); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("Bad -trace-pc %q, expected a range such as C000-C7FF", *traceRange)
+		}
+	}
+
+	console.SetTracer(tracer)
+
+	return func() {
+		if err := tracer.Flush(); err != nil {
+			fmt.Println(err.Error())
+		}
+
+		f.Close()
+	}, nil
+}
+
+func main() {
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Println("Please specify a ROM file")
+		return
+	}
+
+	console := nes.NewConsole()
+
+	if *palette != "" {
+		p, err := loadPalette()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		console.Ppu.SetPalette(p)
+	}
+
+	if contents, err := ioutil.ReadFile(flag.Arg(0)); err == nil {
+
+		if err = console.LoadRom(contents); err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		if h, err := cartridge.ReadHeader(contents); err == nil {
+			fmt.Printf("-----------------\n%s-----------------\n", h)
+		}
+
+		// Set the game name for save states
+		path := strings.Split(flag.Arg(0), "/")
+		gamename = strings.Split(path[len(path)-1], ".")[0]
+		batteryRamFile = fmt.Sprintf(".%s.battery", gamename)
+
+		if console.Rom.BatteryBacked() {
+			if err = console.LoadBatteryRam(batteryRamFile); err != nil {
+				fmt.Println(err.Error())
+			}
+
+			// Run keeps this up to date and flushes it on the way out
+			console.BatteryFile = batteryRamFile
+			console.AutosaveFailed = func(err error) {
+				fmt.Println("Couldn't save battery RAM:", err.Error())
+			}
+		}
+	} else {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if *traceFile != "" {
+		closeTrace, err := openTrace(console)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		defer closeTrace()
+	}
+
+	video.Init(console, gamename)
+	defer video.Close()
+
+	// Cancelled when the window closes or we're told to stop
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Main runloop, in a separate goroutine so that
+	// the video rendering can happen on this one
+	done := make(chan error, 1)
+
+	go func() {
+		var halted bool
+
+		// The console runs at its own pace, the window shows
+		// whichever frame is newest when it gets around to drawing
+		done <- console.Run(ctx, func(frame *nes.Frame, err error) {
+			video.ShowFrame(frame)
+
+			// A jammed CPU is only reported once, the PPU keeps running
+			// so the last picture stays on screen until a reset
+			if (err != nil) != halted {
+				if err != nil {
+					fmt.Println(err.Error())
+				}
+
+				video.ShowError(err)
+				halted = err != nil
+			}
+		})
+	}()
+
+	// This needs to happen on the main thread for OSX
+	runtime.LockOSThread()
+	video.Render(ctx)
+
+	// Wait for the runloop to stop and save the battery
+	cancel()
+	if err := <-done; err != nil && err != context.Canceled {
+		fmt.Println(err.Error())
+	}
+}
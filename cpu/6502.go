@@ -0,0 +1,897 @@
+// Package cpu implements the NES's Ricoh 2A03, a 6502 without decimal
+// mode. The CPU reaches memory through a Bus supplied by the console it
+// is plugged into.
+package cpu
+
+import (
+	"fmt"
+)
+
+// IRQ sources. They share the one IRQ line, each asserting and
+// acknowledging it independently of the others.
+const (
+	IrqMapper = 1 << iota
+	IrqFrameCounter
+	IrqDmc
+)
+
+// Interrupt vectors
+const (
+	nmiVector   = 0xFFFA
+	resetVector = 0xFFFC
+	irqVector   = 0xFFFE
+)
+
+// InvalidOpcodeError is returned by Step when the CPU fetches an opcode
+// it can't execute. The CPU is jammed from then on until it's reset.
+type InvalidOpcodeError struct {
+	Opcode         byte
+	ProgramCounter int
+
+	// PRG bank mapped at ProgramCounter, or -1 when unknown. The CPU
+	// can't see the cartridge so the console fills this in.
+	Bank int
+}
+
+func (e *InvalidOpcodeError) Error() string {
+	return fmt.Sprintf("Invalid opcode at 0x%X (bank %d): 0x%X", e.ProgramCounter, e.Bank, e.Opcode)
+}
+
+// Bus is the CPU's view of the address space
+type Bus interface {
+	Read(a uint16) byte
+	Write(a uint16, v byte)
+}
+
+type Cpu struct {
+	Bus     Bus
+	Variant Variant
+
+	X              byte
+	Y              byte
+	A              byte
+	P              byte
+	CycleCount     int
+	StackPointer   byte
+	Opcode         byte
+	ProgramCounter int
+	Accurate       bool
+
+	// Called before each instruction is fetched, with PC on its opcode
+	Trace func(c *Cpu)
+
+	Timestamp int
+
+	// CPU cycles run since power on
+	Cycles uint64
+
+	// Jammed is set once the CPU has hit an invalid opcode. A jammed
+	// CPU stops fetching instructions until it's reset.
+	Jammed bool
+	jam    *InvalidOpcodeError
+
+	// The instruction in progress, nil between instructions, and how
+	// many of its cycles have run
+	op    *opcode
+	cycle int
+
+	// The variant's opcode table
+	opcodes *[0x100]opcode
+
+	// Set by a 65C02 decimal ADC or SBC, which takes another cycle
+	fixDecimal bool
+
+	// Working state carried from one cycle of an instruction to the
+	// next, the way the 6502 holds it in its internal latches
+	address int
+	pointer byte
+	data    byte
+	carry   bool
+
+	// IRQ sources holding the IRQ line, the level of the NMI line and
+	// its level when it was last sampled
+	irqLine     int
+	nmiLine     bool
+	nmiSampled  bool
+	resetButton bool
+
+	// Interrupts are polled at the end of every cycle. An instruction
+	// acts on what was polled at the end of its second to last cycle,
+	// so each is kept along with the poll before it.
+	irqDue     bool
+	prevIrqDue bool
+	nmiDue     bool
+	prevNmiDue bool
+	holdPoll   bool
+
+	// Vector the interrupt sequence in progress will jump through
+	vector int
+
+	dma dma
+}
+
+func (c *Cpu) getCarry() bool {
+	return c.P&0x01 == 0x01
+}
+
+func (c *Cpu) getZero() bool {
+	return c.P&0x02 == 0x02
+}
+
+func (c *Cpu) getIrqDisable() bool {
+	return c.P&0x04 == 0x04
+}
+
+func (c *Cpu) getDecimalMode() bool {
+	return c.P&0x08 == 0x08
+}
+
+func (c *Cpu) getBrkCommand() bool {
+	return c.P&0x10 == 0x10
+}
+
+func (c *Cpu) getOverflow() bool {
+	return c.P&0x40 == 0x40
+}
+
+func (c *Cpu) getNegative() bool {
+	return c.P&0x80 == 0x80
+}
+
+func (c *Cpu) setCarry() {
+	c.P = c.P | 0x01
+}
+
+func (c *Cpu) setZero() {
+	c.P = c.P | 0x02
+}
+
+func (c *Cpu) setIrqDisable() {
+	c.P = c.P | 0x04
+}
+
+func (c *Cpu) setDecimalMode() {
+	c.P = c.P | 0x08
+}
+
+func (c *Cpu) setBrkCommand() {
+	c.P = c.P | 0x10
+}
+
+func (c *Cpu) setOverflow() {
+	c.P = c.P | 0x40
+}
+
+func (c *Cpu) setNegative() {
+	c.P = c.P | 0x80
+}
+
+func (c *Cpu) clearCarry() {
+	c.P = c.P & 0xFE
+}
+
+func (c *Cpu) clearZero() {
+	c.P = c.P & 0xFD
+}
+
+func (c *Cpu) clearIrqDisable() {
+	c.P = c.P & 0xFB
+}
+
+func (c *Cpu) clearDecimalMode() {
+	c.P = c.P & 0xF7
+}
+
+func (c *Cpu) clearBrkCommand() {
+	c.P = c.P & 0xEF
+}
+
+func (c *Cpu) clearOverflow() {
+	c.P = c.P & 0xBF
+}
+
+func (c *Cpu) clearNegative() {
+	c.P = c.P & 0x7F
+}
+
+// Addresses are carried around as ints and wrap to 16 bits on
+// their way onto the bus
+func (c *Cpu) read(a int) byte {
+	return c.Bus.Read(uint16(a))
+}
+
+func (c *Cpu) write(a int, v byte) {
+	c.Bus.Write(uint16(a), v)
+}
+
+func (c *Cpu) pushToStack(value byte) {
+	c.write(0x100+int(c.StackPointer), value)
+	c.StackPointer--
+}
+
+func (c *Cpu) pullFromStack() byte {
+	c.StackPointer++
+	val := c.read(0x100 + int(c.StackPointer))
+
+	return val
+}
+
+func (c *Cpu) testAndSetNegative(value byte) {
+	if value&0x80 == 0x80 {
+		c.setNegative()
+		return
+	}
+
+	c.clearNegative()
+}
+
+func (c *Cpu) testAndSetZero(value byte) {
+	if value == 0x00 {
+		c.setZero()
+		return
+	}
+
+	c.clearZero()
+}
+
+func (c *Cpu) testAndSetCarryAddition(result int) {
+	if result > 0xFF {
+		c.setCarry()
+		return
+	}
+
+	c.clearCarry()
+}
+
+func (c *Cpu) testAndSetCarrySubtraction(result int) {
+	if result < 0x00 {
+		c.clearCarry()
+		return
+	}
+
+	c.setCarry()
+}
+
+func (c *Cpu) testAndSetOverflowAddition(a byte, b byte, r byte) {
+	if ((a^b)&0x80 == 0x0) && ((a^r)&0x80 == 0x80) {
+		c.setOverflow()
+	} else {
+		c.clearOverflow()
+	}
+}
+
+func (c *Cpu) testAndSetOverflowSubtraction(a byte, b byte) {
+	val := a - b - (1 - c.P&0x01)
+	if ((a^val)&0x80) != 0 && ((a^b)&0x80) != 0 {
+		c.setOverflow()
+	} else {
+		c.clearOverflow()
+	}
+}
+
+func (c *Cpu) Adc(val byte) {
+	if c.decimal() {
+		c.adcDecimal(val)
+		return
+	}
+
+	cached := c.A
+
+	c.A = cached + val + (c.P & 0x01)
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+	c.testAndSetOverflowAddition(cached, val, c.A)
+	c.testAndSetCarryAddition(int(cached) + int(val) + int(c.P&0x01))
+}
+
+func (c *Cpu) Lda(val byte) {
+	c.A = val
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+}
+
+func (c *Cpu) Ldx(val byte) {
+	c.X = val
+
+	c.testAndSetNegative(c.X)
+	c.testAndSetZero(c.X)
+}
+
+func (c *Cpu) Ldy(val byte) {
+	c.Y = val
+
+	c.testAndSetNegative(c.Y)
+	c.testAndSetZero(c.Y)
+}
+
+func (c *Cpu) Sta() byte {
+	return c.A
+}
+
+func (c *Cpu) Stx() byte {
+	return c.X
+}
+
+func (c *Cpu) Sty() byte {
+	return c.Y
+}
+
+func (c *Cpu) Tax() {
+	c.X = c.A
+
+	c.testAndSetNegative(c.X)
+	c.testAndSetZero(c.X)
+}
+
+func (c *Cpu) Txa() {
+	c.A = c.X
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+}
+
+func (c *Cpu) Dex() {
+	c.X = c.X - 1
+
+	if c.X == 0 {
+		c.setZero()
+	}
+
+	c.testAndSetNegative(c.X)
+	c.testAndSetZero(c.X)
+}
+
+func (c *Cpu) Inx() {
+	c.X = c.X + 1
+
+	c.testAndSetNegative(c.X)
+	c.testAndSetZero(c.X)
+}
+
+func (c *Cpu) Tay() {
+	c.Y = c.A
+
+	c.testAndSetNegative(c.Y)
+	c.testAndSetZero(c.Y)
+}
+
+func (c *Cpu) Tya() {
+	c.A = c.Y
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+}
+
+func (c *Cpu) Dey() {
+	c.Y = c.Y - 1
+
+	if c.X == 0 {
+		c.setZero()
+	}
+
+	c.testAndSetNegative(c.Y)
+	c.testAndSetZero(c.Y)
+}
+
+func (c *Cpu) Iny() {
+	c.Y = c.Y + 1
+
+	c.testAndSetNegative(c.Y)
+	c.testAndSetZero(c.Y)
+}
+
+// The branches only decide whether they're taken, the branch cycles
+// move the program counter
+func (c *Cpu) Bpl() bool {
+	return !c.getNegative()
+}
+
+func (c *Cpu) Bmi() bool {
+	return c.getNegative()
+}
+
+func (c *Cpu) Bvc() bool {
+	return !c.getOverflow()
+}
+
+func (c *Cpu) Bvs() bool {
+	return c.getOverflow()
+}
+
+func (c *Cpu) Bcc() bool {
+	return !c.getCarry()
+}
+
+func (c *Cpu) Bcs() bool {
+	return c.getCarry()
+}
+
+func (c *Cpu) Bne() bool {
+	return !c.getZero()
+}
+
+func (c *Cpu) Beq() bool {
+	return c.getZero()
+}
+
+func (c *Cpu) Txs() {
+	c.StackPointer = c.X
+}
+
+func (c *Cpu) Tsx() {
+	c.X = c.StackPointer
+
+	c.testAndSetNegative(c.X)
+	c.testAndSetZero(c.X)
+}
+
+func (c *Cpu) Pha() byte {
+	return c.A
+}
+
+func (c *Cpu) Pla(val byte) {
+	c.A = val
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+}
+
+func (c *Cpu) Php() byte {
+	// BRK and PHP push P OR #$10, so that the IRQ handler can tell
+	// whether the entry was from a BRK or from an /IRQ.
+	return c.P | 0x10
+}
+
+func (c *Cpu) Plp(val byte) {
+	// Unset bit 5 since it's unused in the NES
+	c.P = (val | 0x30) - 0x10
+}
+
+func (c *Cpu) Compare(register byte, value byte) {
+	r := register - value
+
+	c.testAndSetZero(r)
+	c.testAndSetNegative(r)
+	c.testAndSetCarrySubtraction(int(register) - int(value))
+}
+
+func (c *Cpu) Cmp(val byte) {
+	c.Compare(c.A, val)
+}
+
+func (c *Cpu) Cpx(val byte) {
+	c.Compare(c.X, val)
+}
+
+func (c *Cpu) Cpy(val byte) {
+	c.Compare(c.Y, val)
+}
+
+func (c *Cpu) Sbc(val byte) {
+	cache := c.A
+
+	var bcd byte
+	if c.decimal() {
+		bcd = c.decimalSubtract(cache, val)
+	}
+
+	c.A = cache - val
+
+	c.A = c.A - (1 - c.P&0x01)
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+	c.testAndSetOverflowSubtraction(cache, val)
+	c.testAndSetCarrySubtraction(int(cache) - int(val) - (1 - int(c.P&0x01)))
+
+	// The NMOS 6502 leaves the flags as they'd be in binary
+	if c.decimal() {
+		c.A = bcd
+
+		if c.Variant == Cmos65C02 {
+			c.testAndSetNegative(c.A)
+			c.testAndSetZero(c.A)
+			c.fixDecimal = true
+		}
+	}
+}
+
+func (c *Cpu) Clc() {
+	c.clearCarry()
+}
+
+func (c *Cpu) Sec() {
+	c.setCarry()
+}
+
+func (c *Cpu) Cli() {
+	c.clearIrqDisable()
+}
+
+func (c *Cpu) Sei() {
+	c.setIrqDisable()
+}
+
+func (c *Cpu) Clv() {
+	c.clearOverflow()
+}
+
+func (c *Cpu) Cld() {
+	c.clearDecimalMode()
+}
+
+func (c *Cpu) Sed() {
+	c.setDecimalMode()
+}
+
+func (c *Cpu) Nop() {
+}
+
+func (c *Cpu) And(val byte) {
+	c.A = c.A & val
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+}
+
+func (c *Cpu) Ora(val byte) {
+	c.A = c.A | val
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+}
+
+func (c *Cpu) Eor(val byte) {
+	c.A = c.A ^ val
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+}
+
+func (c *Cpu) Dec(val byte) byte {
+	val = val - 1
+
+	c.testAndSetNegative(val)
+	c.testAndSetZero(val)
+
+	return val
+}
+
+func (c *Cpu) Inc(val byte) byte {
+	val = val + 1
+
+	c.testAndSetNegative(val)
+	c.testAndSetZero(val)
+
+	return val
+}
+
+func (c *Cpu) Lsr(val byte) byte {
+	if val&0x01 > 0x00 {
+		c.setCarry()
+	} else {
+		c.clearCarry()
+	}
+
+	val = val >> 1
+
+	c.testAndSetNegative(val)
+	c.testAndSetZero(val)
+
+	return val
+}
+
+func (c *Cpu) LsrAcc() {
+	c.A = c.Lsr(c.A)
+}
+
+func (c *Cpu) Asl(val byte) byte {
+	if val&0x80 > 0 {
+		c.setCarry()
+	} else {
+		c.clearCarry()
+	}
+
+	val = val << 1
+
+	c.testAndSetNegative(val)
+	c.testAndSetZero(val)
+
+	return val
+}
+
+func (c *Cpu) AslAcc() {
+	c.A = c.Asl(c.A)
+}
+
+func (c *Cpu) Rol(value byte) byte {
+	carry := value & 0x80
+
+	value = value << 1
+
+	if c.getCarry() {
+		value += 1
+	}
+
+	if carry > 0x00 {
+		c.setCarry()
+	} else {
+		c.clearCarry()
+	}
+
+	c.testAndSetNegative(value)
+	c.testAndSetZero(value)
+
+	return value
+}
+
+func (c *Cpu) RolAcc() {
+	c.A = c.Rol(c.A)
+}
+
+func (c *Cpu) Ror(value byte) byte {
+	carry := value & 0x1
+
+	value = value >> 1
+
+	if c.getCarry() {
+		value += 0x80
+	}
+
+	if carry > 0x00 {
+		c.setCarry()
+	} else {
+		c.clearCarry()
+	}
+
+	c.testAndSetNegative(value)
+	c.testAndSetZero(value)
+
+	return value
+}
+
+func (c *Cpu) RorAcc() {
+	c.A = c.Ror(c.A)
+}
+
+func (c *Cpu) Bit(val byte) {
+	if val&c.A == 0 {
+		c.setZero()
+	} else {
+		c.clearZero()
+	}
+
+	if val&0x80 > 0x00 {
+		c.setNegative()
+	} else {
+		c.clearNegative()
+	}
+
+	if val&0x40 > 0x00 {
+		c.setOverflow()
+	} else {
+		c.clearOverflow()
+	}
+}
+
+// AssertIrq pulls the IRQ line on behalf of source. The line stays
+// active, and the CPU keeps taking IRQs while I is clear, until every
+// source holding it has acknowledged.
+func (c *Cpu) AssertIrq(source int) {
+	c.irqLine |= source
+}
+
+// AcknowledgeIrq lets go of the IRQ line for source
+func (c *Cpu) AcknowledgeIrq(source int) {
+	c.irqLine &^= source
+}
+
+// SetNmi sets the level of the NMI line. The CPU takes an NMI each
+// time the line becomes active.
+func (c *Cpu) SetNmi(active bool) {
+	c.nmiLine = active
+}
+
+// Reset presses the reset button. Once the instruction in progress
+// is done the CPU runs its reset sequence, which jumps through the
+// reset vector and also brings back a jammed CPU.
+func (c *Cpu) Reset() {
+	c.resetButton = true
+}
+
+// Samples the interrupt lines at the end of a cycle
+func (c *Cpu) poll() {
+	// Keep what the cycle before saw when this one doesn't poll
+	if !c.holdPoll {
+		c.prevIrqDue = c.irqDue
+		c.prevNmiDue = c.nmiDue
+	}
+	c.holdPoll = false
+
+	c.irqDue = c.irqLine != 0 && !c.getIrqDisable()
+
+	if c.nmiLine && !c.nmiSampled {
+		c.nmiDue = true
+	}
+	c.nmiSampled = c.nmiLine
+}
+
+// NewCpu returns a 2A03, the NES's CPU
+func NewCpu(bus Bus) *Cpu {
+	return NewVariantCpu(bus, Ricoh2A03)
+}
+
+func NewVariantCpu(bus Bus, v Variant) *Cpu {
+	c := &Cpu{Bus: bus, Variant: v, opcodes: v.opcodes()}
+	c.Init()
+
+	return c
+}
+
+// Init puts the CPU in its power on state
+func (c *Cpu) Init() {
+	c.X = 0
+	c.Y = 0
+	c.A = 0
+	c.CycleCount = 0
+	c.P = 0x34
+	c.StackPointer = 0xFD
+
+	c.Accurate = true
+
+	c.Jammed = false
+	c.jam = nil
+
+	c.op = nil
+	c.cycle = 0
+	c.dma = dma{}
+
+	c.irqLine = 0
+	c.nmiLine = false
+	c.nmiSampled = false
+	c.resetButton = false
+
+	c.irqDue = false
+	c.prevIrqDue = false
+	c.nmiDue = false
+	c.prevNmiDue = false
+	c.holdPoll = false
+}
+
+// Clock runs the CPU for a single cycle, making the one bus access
+// the 2A03 makes on that cycle. done is set once the cycle finishes
+// an instruction, an interrupt or a DMA.
+// Once the CPU is jammed every cycle returns the error that jammed
+// it.
+func (c *Cpu) Clock() (done bool, err error) {
+	c.Cycles++
+	defer c.poll()
+
+	if c.op != nil {
+		done = c.op.cycles[c.cycle](c)
+
+		if done && c.fixDecimal {
+			c.fixDecimal = false
+			c.op, c.cycle, done = &decimalFixup, -1, false
+		}
+
+		c.cycle++
+		c.CycleCount++
+
+		if done {
+			c.op = nil
+			c.Timestamp = (c.CycleCount * 15)
+		}
+
+		return
+	}
+
+	// Halted for a DMA, the CPU sits out a cycle at a time
+	if c.dmaActive() {
+		return c.clockDma(), nil
+	}
+
+	// Only a reset brings back a jammed CPU
+	if c.Jammed && !c.resetButton {
+		return true, c.jam
+	}
+
+	c.CycleCount = 1
+	c.cycle = 0
+
+	// Taking an interrupt replaces the opcode fetch, the handler's
+	// first instruction is fetched once it's done
+	switch {
+	case c.resetButton:
+		c.resetButton = false
+		c.Jammed = false
+		c.jam = nil
+
+		c.vector = resetVector
+		c.startInterrupt(&resetSequence)
+		return
+	case c.prevNmiDue || c.prevIrqDue:
+		c.startInterrupt(&interruptSequence)
+		return
+	}
+
+	if c.Trace != nil {
+		c.Trace(c)
+	}
+
+	opcode := c.read(c.ProgramCounter)
+
+	c.Opcode = opcode
+
+	op := &c.opcodes[opcode]
+
+	if op.cycles == nil {
+		// KIL jams the CPU. Leave the program counter on the bad opcode
+		c.Jammed = true
+		c.jam = &InvalidOpcodeError{
+			Opcode:         opcode,
+			ProgramCounter: c.ProgramCounter,
+			Bank:           -1,
+		}
+
+		return true, c.jam
+	}
+
+	c.ProgramCounter++
+
+	// The 65C02's single byte NOPs are done on the fetch
+	if len(op.cycles) == 0 {
+		return true, nil
+	}
+
+	c.op = op
+
+	return
+}
+
+// Executing reports whether the CPU is partway through an
+// instruction or interrupt, or halted for a DMA
+func (c *Cpu) Executing() bool {
+	return c.op != nil || c.dmaActive()
+}
+
+// Abort drops the instruction, interrupt or DMA in progress and any
+// interrupt that was about to be taken, so the CPU fetches its next
+// instruction from ProgramCounter. It's for when the registers have
+// been loaded from a save state.
+func (c *Cpu) Abort() {
+	c.op = nil
+	c.cycle = 0
+	c.fixDecimal = false
+	c.dma = dma{}
+
+	c.Jammed = false
+	c.jam = nil
+
+	c.irqDue = false
+	c.prevIrqDue = false
+	c.nmiDue = false
+	c.prevNmiDue = false
+	c.holdPoll = false
+}
+
+// The opcode fetch of an interrupt still reads from the program
+// counter, it just doesn't move it
+func (c *Cpu) startInterrupt(sequence *opcode) {
+	c.read(c.ProgramCounter)
+	c.op = sequence
+}
+
+// Step runs the CPU until it finishes the instruction or interrupt
+// in progress, or the next one if it's between instructions, and
+// returns the number of cycles that took. A DMA the CPU is halted for
+// counts as a step of its own.
+func (c *Cpu) Step() (cycles int, err error) {
+	for done := false; !done; cycles++ {
+		done, err = c.Clock()
+	}
+
+	return
+}
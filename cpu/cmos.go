@@ -0,0 +1,205 @@
+package cpu
+
+// The 65C02's instruction set. It keeps every official 6502 opcode,
+// adds new ones in the holes and makes the rest NOPs of fixed sizes.
+//
+// http://www.6502.org/tutorials/65c02opcodes.html
+var cmosOpcodes = cmosTable()
+
+func cmosTable() [0x100]opcode {
+	var t [0x100]opcode
+
+	for i, o := range opcodes {
+		switch {
+		case o.Official:
+			t[i] = o
+		case i&0x0F == 0x02:
+			t[i] = readOp("NOP", Immediate, (*Cpu).Ign)
+		default:
+			// Columns 3, 7, B and F are single byte NOPs that take one
+			// cycle, they're over as soon as they're fetched
+			t[i] = newOpcode("NOP", Implied, AccessNone, []microOp{})
+		}
+	}
+
+	// The remaining NOPs
+	t[0x44] = readOp("NOP", ZeroPage, (*Cpu).Ign)
+	t[0x54] = readOp("NOP", ZeroPageX, (*Cpu).Ign)
+	t[0xD4] = readOp("NOP", ZeroPageX, (*Cpu).Ign)
+	t[0xF4] = readOp("NOP", ZeroPageX, (*Cpu).Ign)
+	t[0xDC] = readOp("NOP", Absolute, (*Cpu).Ign)
+	t[0xFC] = readOp("NOP", Absolute, (*Cpu).Ign)
+	t[0x5C] = newOpcode("NOP", Absolute, AccessRead, nop5cCycles)
+	t[0x5C].read = (*Cpu).Ign
+
+	// (zp) versions of the instructions that have (zp,X) and (zp),Y
+	t[0x12] = readOp("ORA", ZeroPageIndirect, (*Cpu).Ora)
+	t[0x32] = readOp("AND", ZeroPageIndirect, (*Cpu).And)
+	t[0x52] = readOp("EOR", ZeroPageIndirect, (*Cpu).Eor)
+	t[0x72] = readOp("ADC", ZeroPageIndirect, (*Cpu).Adc)
+	t[0x92] = writeOp("STA", ZeroPageIndirect, (*Cpu).Sta)
+	t[0xB2] = readOp("LDA", ZeroPageIndirect, (*Cpu).Lda)
+	t[0xD2] = readOp("CMP", ZeroPageIndirect, (*Cpu).Cmp)
+	t[0xF2] = readOp("SBC", ZeroPageIndirect, (*Cpu).Sbc)
+
+	// BIT
+	t[0x89] = readOp("BIT", Immediate, (*Cpu).BitImmediate)
+	t[0x34] = readOp("BIT", ZeroPageX, (*Cpu).Bit)
+	t[0x3C] = readOp("BIT", AbsoluteX, (*Cpu).Bit)
+	// TSB
+	t[0x04] = modifyOp("TSB", ZeroPage, (*Cpu).Tsb)
+	t[0x0C] = modifyOp("TSB", Absolute, (*Cpu).Tsb)
+	// TRB
+	t[0x14] = modifyOp("TRB", ZeroPage, (*Cpu).Trb)
+	t[0x1C] = modifyOp("TRB", Absolute, (*Cpu).Trb)
+	// INC, DEC
+	t[0x1A] = accumulatorOp("INC", (*Cpu).IncAcc)
+	t[0x3A] = accumulatorOp("DEC", (*Cpu).DecAcc)
+	// PHX, PHY, PLX, PLY
+	t[0xDA] = pushOp("PHX", (*Cpu).Phx)
+	t[0x5A] = pushOp("PHY", (*Cpu).Phy)
+	t[0xFA] = pullOp("PLX", (*Cpu).Plx)
+	t[0x7A] = pullOp("PLY", (*Cpu).Ply)
+	// STZ
+	t[0x64] = writeOp("STZ", ZeroPage, (*Cpu).Stz)
+	t[0x74] = writeOp("STZ", ZeroPageX, (*Cpu).Stz)
+	t[0x9C] = writeOp("STZ", Absolute, (*Cpu).Stz)
+	t[0x9E] = writeOp("STZ", AbsoluteX, (*Cpu).Stz)
+	// BRA, always taken
+	t[0x80] = branchOp("BRA", (*Cpu).Bra)
+	t[0x80].Cycles = 3
+	// JMP
+	t[0x6C] = controlOp("JMP", Indirect, cmosJmpIndirectCycles)
+	t[0x7C] = controlOp("JMP", AbsoluteIndirectX, jmpIndexedIndirectCycles)
+
+	for i := range t {
+		o := &t[i]
+
+		if o.Access != AccessModify {
+			continue
+		}
+
+		// Shifts and rotates only spend the cycle fixing the high byte
+		// when the index carries into it
+		if o.Mode == AbsoluteX && o.Name != "INC" && o.Name != "DEC" {
+			o.cycles = cmosShiftIndexedCycles
+			o.Cycles--
+			continue
+		}
+
+		o.cycles = cmosModifyCycles(o.cycles)
+	}
+
+	return t
+}
+
+// Read-modify-writes read the address a second time where the NMOS
+// parts write back the value they read
+func cmosModifyCycles(cycles []microOp) []microOp {
+	c := make([]microOp, len(cycles))
+	copy(c, cycles)
+	c[len(c)-2] = modifyDataRereading
+
+	return c
+}
+
+var cmosShiftIndexedCycles = []microOp{fetchAddressLow, fetchAddressHighAddX, readDataFixingIndex, modifyDataRereading, writeData}
+
+func modifyDataRereading(c *Cpu) bool {
+	c.read(c.address)
+	c.data = c.op.modify(c, c.data)
+
+	return false
+}
+
+// Spends a cycle on the dummy read and comes back to itself when the
+// high byte needs fixing, otherwise reads the data
+func readDataFixingIndex(c *Cpu) bool {
+	if c.carry {
+		fixIndexed(c)
+		c.carry = false
+		c.cycle--
+
+		return false
+	}
+
+	return readData(c)
+}
+
+// $5C reads its operand, then spends five more cycles going nowhere
+var nop5cCycles = []microOp{fetchAddressLow, fetchAddressHigh, readData, readData, readData, readData, readOperand}
+
+// Indirect jumps take a cycle longer than on the NMOS parts, and read
+// the high byte of the target from the right page
+var cmosJmpIndirectCycles = []microOp{fetchAddressLow, fetchAddressHigh, rereadAddressHigh, readData, jumpIndirectFixed}
+var jmpIndexedIndirectCycles = []microOp{fetchAddressLow, fetchAddressHigh, addXToAddress, readData, jumpIndirectFixed}
+
+func rereadAddressHigh(c *Cpu) bool {
+	c.read(c.ProgramCounter - 1)
+
+	return false
+}
+
+func addXToAddress(c *Cpu) bool {
+	rereadAddressHigh(c)
+	c.address = (c.address + int(c.X)) & 0xFFFF
+
+	return false
+}
+
+func jumpIndirectFixed(c *Cpu) bool {
+	high := c.read((c.address + 1) & 0xFFFF)
+	c.ProgramCounter = int(high)<<8 | int(c.data)
+
+	return true
+}
+
+// BIT #imm only has an operand to test against A, it leaves N and V
+// alone
+func (c *Cpu) BitImmediate(val byte) {
+	c.testAndSetZero(val & c.A)
+}
+
+func (c *Cpu) Tsb(val byte) byte {
+	c.testAndSetZero(val & c.A)
+
+	return val | c.A
+}
+
+func (c *Cpu) Trb(val byte) byte {
+	c.testAndSetZero(val & c.A)
+
+	return val &^ c.A
+}
+
+func (c *Cpu) IncAcc() {
+	c.A = c.Inc(c.A)
+}
+
+func (c *Cpu) DecAcc() {
+	c.A = c.Dec(c.A)
+}
+
+func (c *Cpu) Phx() byte {
+	return c.X
+}
+
+func (c *Cpu) Phy() byte {
+	return c.Y
+}
+
+func (c *Cpu) Plx(val byte) {
+	c.Ldx(val)
+}
+
+func (c *Cpu) Ply(val byte) {
+	c.Ldy(val)
+}
+
+func (c *Cpu) Stz() byte {
+	return 0
+}
+
+func (c *Cpu) Bra() bool {
+	return true
+}
@@ -0,0 +1,549 @@
+package cpu
+
+// Instructions run a cycle at a time. Each opcode has a list of
+// micro-ops, one per cycle after the opcode fetch, and every micro-op
+// makes exactly the one bus access the 6502 makes on that cycle,
+// dummy reads and writes included. A micro-op returns true when it
+// finishes the instruction.
+//
+// http://nesdev.com/6502_cpu.txt
+type microOp func(c *Cpu) bool
+
+type opcode struct {
+	Instruction
+
+	// Loads, arithmetic and compares take the operand, stores return
+	// the value to write and read-modify-writes both
+	read    func(c *Cpu, v byte)
+	write   func(c *Cpu) byte
+	modify  func(c *Cpu, v byte) byte
+	implied func(c *Cpu)
+	branch  func(c *Cpu) bool
+
+	// nil for the opcodes that jam the CPU
+	cycles []microOp
+}
+
+// Indexed reads finish a cycle early when the index doesn't carry into
+// the high byte, the read from the unfixed address is the real one.
+// Stores and read-modify-writes always spend that cycle on a dummy
+// read.
+var readCycles = [...][]microOp{
+	Immediate: {readImmediate},
+	ZeroPage:  {fetchAddressLow, readOperand},
+	ZeroPageX: {fetchAddressLow, addXToZeroPage, readOperand},
+	ZeroPageY: {fetchAddressLow, addYToZeroPage, readOperand},
+	Absolute:  {fetchAddressLow, fetchAddressHigh, readOperand},
+	AbsoluteX: {fetchAddressLow, fetchAddressHighAddX, readIndexed, readOperand},
+	AbsoluteY: {fetchAddressLow, fetchAddressHighAddY, readIndexed, readOperand},
+	IndirectX: {fetchPointer, addXToPointer, readPointerLow, readPointerHigh, readOperand},
+	IndirectY: {fetchPointer, readPointerLow, readPointerHighAddY, readIndexed, readOperand},
+
+	ZeroPageIndirect: {fetchPointer, readPointerLow, readPointerHigh, readOperand},
+}
+
+var writeCycles = [...][]microOp{
+	ZeroPage:  {fetchAddressLow, writeOperand},
+	ZeroPageX: {fetchAddressLow, addXToZeroPage, writeOperand},
+	ZeroPageY: {fetchAddressLow, addYToZeroPage, writeOperand},
+	Absolute:  {fetchAddressLow, fetchAddressHigh, writeOperand},
+	AbsoluteX: {fetchAddressLow, fetchAddressHighAddX, fixIndexed, writeOperand},
+	AbsoluteY: {fetchAddressLow, fetchAddressHighAddY, fixIndexed, writeOperand},
+	IndirectX: {fetchPointer, addXToPointer, readPointerLow, readPointerHigh, writeOperand},
+	IndirectY: {fetchPointer, readPointerLow, readPointerHighAddY, fixIndexed, writeOperand},
+
+	ZeroPageIndirect: {fetchPointer, readPointerLow, readPointerHigh, writeOperand},
+}
+
+// Read-modify-writes write the value they read straight back while
+// they work on it, then write the result
+var modifyCycles = [...][]microOp{
+	ZeroPage:  {fetchAddressLow, readData, modifyData, writeData},
+	ZeroPageX: {fetchAddressLow, addXToZeroPage, readData, modifyData, writeData},
+	Absolute:  {fetchAddressLow, fetchAddressHigh, readData, modifyData, writeData},
+	AbsoluteX: {fetchAddressLow, fetchAddressHighAddX, fixIndexed, readData, modifyData, writeData},
+	AbsoluteY: {fetchAddressLow, fetchAddressHighAddY, fixIndexed, readData, modifyData, writeData},
+	IndirectX: {fetchPointer, addXToPointer, readPointerLow, readPointerHigh, readData, modifyData, writeData},
+	IndirectY: {fetchPointer, readPointerLow, readPointerHighAddY, fixIndexed, readData, modifyData, writeData},
+}
+
+var branchCycles = []microOp{fetchBranch, takeBranch, fixBranch}
+var impliedCycles = []microOp{impliedCycle}
+
+// Fills in the description of an official opcode from its cycles
+func newOpcode(name string, mode Mode, access int, cycles []microOp) opcode {
+	return opcode{
+		Instruction: Instruction{
+			Name:     name,
+			Mode:     mode,
+			Size:     modeSizes[mode],
+			Access:   access,
+			Official: true,
+			Cycles:   1 + len(cycles),
+		},
+		cycles: cycles,
+	}
+}
+
+func readOp(name string, mode Mode, op func(c *Cpu, v byte)) opcode {
+	o := newOpcode(name, mode, AccessRead, readCycles[mode])
+	o.read = op
+
+	// The cycle that fixes the high byte is only spent on a carry
+	if mode == AbsoluteX || mode == AbsoluteY || mode == IndirectY {
+		o.Cycles--
+	}
+
+	return o
+}
+
+func writeOp(name string, mode Mode, op func(c *Cpu) byte) opcode {
+	o := newOpcode(name, mode, AccessWrite, writeCycles[mode])
+	o.write = op
+
+	return o
+}
+
+func modifyOp(name string, mode Mode, op func(c *Cpu, v byte) byte) opcode {
+	o := newOpcode(name, mode, AccessModify, modifyCycles[mode])
+	o.modify = op
+
+	return o
+}
+
+func impliedOp(name string, op func(c *Cpu)) opcode {
+	o := newOpcode(name, Implied, AccessNone, impliedCycles)
+	o.implied = op
+
+	return o
+}
+
+func accumulatorOp(name string, op func(c *Cpu)) opcode {
+	o := impliedOp(name, op)
+	o.Mode = Accumulator
+
+	return o
+}
+
+func branchOp(name string, op func(c *Cpu) bool) opcode {
+	o := newOpcode(name, Relative, AccessNone, branchCycles)
+	o.branch = op
+
+	// Untaken
+	o.Cycles = 2
+
+	return o
+}
+
+func pushOp(name string, op func(c *Cpu) byte) opcode {
+	o := newOpcode(name, Implied, AccessNone, pushCycles)
+	o.write = op
+
+	return o
+}
+
+func pullOp(name string, op func(c *Cpu, v byte)) opcode {
+	o := newOpcode(name, Implied, AccessNone, pullCycles)
+	o.read = op
+
+	return o
+}
+
+// Jumps, calls, returns and BRK have cycles of their own
+func controlOp(name string, mode Mode, cycles []microOp) opcode {
+	return newOpcode(name, mode, AccessNone, cycles)
+}
+
+// KIL never finishes, the CPU jams on fetching it
+func jamOp() opcode {
+	o := newOpcode("KIL", Implied, AccessNone, nil)
+	o.Cycles = 0
+
+	return o
+}
+
+func unofficial(o opcode) opcode {
+	o.Official = false
+
+	return o
+}
+
+// Implied and accumulator instructions read the byte after the opcode
+// and ignore it
+func impliedCycle(c *Cpu) bool {
+	c.read(c.ProgramCounter)
+	c.op.implied(c)
+
+	return true
+}
+
+func readImmediate(c *Cpu) bool {
+	v := c.read(c.ProgramCounter)
+	c.ProgramCounter++
+
+	c.op.read(c, v)
+	return true
+}
+
+func fetchAddressLow(c *Cpu) bool {
+	c.address = int(c.read(c.ProgramCounter))
+	c.ProgramCounter++
+
+	return false
+}
+
+func fetchAddressHigh(c *Cpu) bool {
+	c.address |= int(c.read(c.ProgramCounter)) << 8
+	c.ProgramCounter++
+
+	return false
+}
+
+// Adds the index to the low byte only. carry records whether the high
+// byte still needs fixing up.
+func (c *Cpu) indexAddress(high byte, index byte) {
+	low := c.address + int(index)
+
+	c.carry = low > 0xFF
+	c.address = int(high)<<8 | low&0xFF
+}
+
+func fetchAddressHighAddX(c *Cpu) bool {
+	c.indexAddress(c.read(c.ProgramCounter), c.X)
+	c.ProgramCounter++
+
+	return false
+}
+
+func fetchAddressHighAddY(c *Cpu) bool {
+	c.indexAddress(c.read(c.ProgramCounter), c.Y)
+	c.ProgramCounter++
+
+	return false
+}
+
+// Zero page indexing reads the unindexed address while it adds, and
+// never leaves the zero page
+func addXToZeroPage(c *Cpu) bool {
+	c.read(c.address)
+	c.address = (c.address + int(c.X)) & 0xFF
+
+	return false
+}
+
+func addYToZeroPage(c *Cpu) bool {
+	c.read(c.address)
+	c.address = (c.address + int(c.Y)) & 0xFF
+
+	return false
+}
+
+func fetchPointer(c *Cpu) bool {
+	c.pointer = c.read(c.ProgramCounter)
+	c.ProgramCounter++
+
+	return false
+}
+
+func addXToPointer(c *Cpu) bool {
+	c.read(int(c.pointer))
+	c.pointer += c.X
+
+	return false
+}
+
+func readPointerLow(c *Cpu) bool {
+	c.address = int(c.read(int(c.pointer)))
+
+	return false
+}
+
+// The pointer wraps around within the zero page
+func readPointerHigh(c *Cpu) bool {
+	c.address |= int(c.read(int(c.pointer+1))) << 8
+
+	return false
+}
+
+func readPointerHighAddY(c *Cpu) bool {
+	c.indexAddress(c.read(int(c.pointer+1)), c.Y)
+
+	return false
+}
+
+func readIndexed(c *Cpu) bool {
+	v := c.read(c.address)
+
+	if !c.carry {
+		c.op.read(c, v)
+		return true
+	}
+
+	c.address = (c.address + 0x100) & 0xFFFF
+	return false
+}
+
+func fixIndexed(c *Cpu) bool {
+	c.read(c.address)
+
+	if c.carry {
+		c.address = (c.address + 0x100) & 0xFFFF
+	}
+
+	return false
+}
+
+func readOperand(c *Cpu) bool {
+	c.op.read(c, c.read(c.address))
+
+	return true
+}
+
+// The unstable stores move the address, so the value is worked out
+// before it's written
+func writeOperand(c *Cpu) bool {
+	v := c.op.write(c)
+	c.write(c.address, v)
+
+	return true
+}
+
+func readData(c *Cpu) bool {
+	c.data = c.read(c.address)
+
+	return false
+}
+
+func modifyData(c *Cpu) bool {
+	c.write(c.address, c.data)
+	c.data = c.op.modify(c, c.data)
+
+	return false
+}
+
+func writeData(c *Cpu) bool {
+	c.write(c.address, c.data)
+
+	return true
+}
+
+// Branches take a cycle to add the offset to the low byte of the
+// program counter, and another to fix the high byte when the target
+// is on a different page than the next instruction
+func fetchBranch(c *Cpu) bool {
+	c.data = c.read(c.ProgramCounter)
+	c.ProgramCounter++
+
+	return !c.op.branch(c)
+}
+
+func takeBranch(c *Cpu) bool {
+	c.read(c.ProgramCounter)
+
+	c.address = (c.ProgramCounter + int(int8(c.data))) & 0xFFFF
+	c.carry = c.address&0xFF00 != c.ProgramCounter&0xFF00
+
+	c.ProgramCounter = c.ProgramCounter&0xFF00 | c.address&0xFF
+
+	// A taken branch that stays on the page doesn't poll for
+	// interrupts on its last cycle, one that shows up during it
+	// waits until after the next instruction
+	if !c.carry {
+		c.holdPoll = true
+	}
+
+	return !c.carry
+}
+
+func fixBranch(c *Cpu) bool {
+	c.read(c.ProgramCounter)
+	c.ProgramCounter = c.address
+
+	return true
+}
+
+var jmpAbsoluteCycles = []microOp{fetchAddressLow, jumpAbsolute}
+
+func jumpAbsolute(c *Cpu) bool {
+	c.ProgramCounter = int(c.read(c.ProgramCounter))<<8 | c.address
+
+	return true
+}
+
+// Indirect jump is bugged on the 6502, it doesn't add 1 to the full
+// 16-bit value when it reads the second byte, it adds 1 to the low
+// byte only. So JMP (03FF) reads from 3FF and 300, not 3FF and 400.
+var jmpIndirectCycles = []microOp{fetchAddressLow, fetchAddressHigh, readData, jumpIndirect}
+
+func jumpIndirect(c *Cpu) bool {
+	high := c.read(c.address&0xFF00 | (c.address+1)&0xFF)
+	c.ProgramCounter = int(high)<<8 | int(c.data)
+
+	return true
+}
+
+// JSR pushes the address of its own last byte, RTS adds the one back
+var jsrCycles = []microOp{fetchAddressLow, peekStack, pushProgramCounterHigh, pushProgramCounterLow, jumpAbsolute}
+var rtsCycles = []microOp{readProgramCounter, peekStack, pullProgramCounterLow, pullProgramCounterHigh, incrementProgramCounter}
+var rtiCycles = []microOp{readProgramCounter, peekStack, pullStatus, pullProgramCounterLow, returnFromInterrupt}
+
+// PHA and PHP use the write op for the value to push, PLA and PLP
+// the read op for the value pulled
+var pushCycles = []microOp{readProgramCounter, push}
+var pullCycles = []microOp{readProgramCounter, peekStack, pull}
+
+func readProgramCounter(c *Cpu) bool {
+	c.read(c.ProgramCounter)
+
+	return false
+}
+
+func incrementProgramCounter(c *Cpu) bool {
+	c.read(c.ProgramCounter)
+	c.ProgramCounter = (c.ProgramCounter + 1) & 0xFFFF
+
+	return true
+}
+
+// The stack pointer is incremented on the cycle before each pull, which
+// reads from the stack without using the value
+func peekStack(c *Cpu) bool {
+	c.read(0x100 + int(c.StackPointer))
+
+	return false
+}
+
+func push(c *Cpu) bool {
+	c.pushToStack(c.op.write(c))
+
+	return true
+}
+
+func pull(c *Cpu) bool {
+	c.op.read(c, c.pullFromStack())
+
+	return true
+}
+
+func pushProgramCounterHigh(c *Cpu) bool {
+	c.pushToStack(byte(c.ProgramCounter >> 8))
+
+	return false
+}
+
+func pushProgramCounterLow(c *Cpu) bool {
+	c.pushToStack(byte(c.ProgramCounter & 0xFF))
+
+	return false
+}
+
+func pullStatus(c *Cpu) bool {
+	c.Plp(c.pullFromStack())
+
+	return false
+}
+
+func pullProgramCounterLow(c *Cpu) bool {
+	c.address = int(c.pullFromStack())
+
+	return false
+}
+
+func pullProgramCounterHigh(c *Cpu) bool {
+	c.ProgramCounter = int(c.pullFromStack())<<8 | c.address
+
+	return false
+}
+
+func returnFromInterrupt(c *Cpu) bool {
+	pullProgramCounterHigh(c)
+
+	return true
+}
+
+// perfect example of the confusion the "B flag exists in status register"
+// causes (pdq, nothing specific to you; this confusion is present in
+// almost every 6502 book and web page).
+//
+// As pdq said, BRK does the following:
+//
+// 1. Push address of BRK instruction + 2
+// 2. PHP
+// 3. SEI
+// 4. JMP ($FFFE)
+//
+// IRQs and NMIs run the same sequence in place of an instruction,
+// except they push P without the B flag. Reset goes through it with
+// the stack writes turned into reads.
+//
+// The vector isn't picked until P is pushed. An NMI that shows up
+// before then hijacks the BRK or IRQ, which jumps through the NMI
+// vector instead.
+var brkCycles = []microOp{fetchPadding, pushProgramCounterHigh, pushProgramCounterLow, pushBrkStatus, readVectorLow, readVectorHigh}
+
+var interruptSequence = opcode{cycles: []microOp{readProgramCounter, pushProgramCounterHigh, pushProgramCounterLow, pushStatus, readVectorLow, readVectorHigh}}
+var resetSequence = opcode{cycles: []microOp{readProgramCounter, resetStack, resetStack, resetStatus, readVectorLow, readVectorHigh}}
+
+func fetchPadding(c *Cpu) bool {
+	c.read(c.ProgramCounter)
+	c.ProgramCounter++
+
+	return false
+}
+
+// The 65C02 also clears D, so handlers start out in binary mode
+func pushBrkStatus(c *Cpu) bool {
+	c.pushToStack(c.Php())
+	c.setIrqDisable()
+	c.clearCmosDecimal()
+	c.selectVector()
+
+	return false
+}
+
+func pushStatus(c *Cpu) bool {
+	c.pushToStack(c.P &^ 0x10)
+	c.setIrqDisable()
+	c.clearCmosDecimal()
+	c.selectVector()
+
+	return false
+}
+
+func (c *Cpu) selectVector() {
+	if c.nmiDue {
+		c.nmiDue = false
+		c.vector = nmiVector
+	} else {
+		c.vector = irqVector
+	}
+}
+
+// Reset still moves the stack pointer for the three pushes, it just
+// reads instead of writing
+func resetStack(c *Cpu) bool {
+	c.read(0x100 + int(c.StackPointer))
+	c.StackPointer--
+
+	return false
+}
+
+func resetStatus(c *Cpu) bool {
+	resetStack(c)
+	c.setIrqDisable()
+	c.clearCmosDecimal()
+
+	return false
+}
+
+func readVectorLow(c *Cpu) bool {
+	c.address = int(c.read(c.vector))
+
+	return false
+}
+
+func readVectorHigh(c *Cpu) bool {
+	c.ProgramCounter = int(c.read(c.vector+1))<<8 | c.address
+
+	return true
+}
@@ -0,0 +1,115 @@
+package cpu
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// 64k of RAM that logs every access
+type recordingBus struct {
+	memory   [0x10000]byte
+	accesses []string
+}
+
+func (b *recordingBus) Read(a uint16) byte {
+	b.accesses = append(b.accesses, fmt.Sprintf("read %04X", a))
+	return b.memory[a]
+}
+
+func (b *recordingBus) Write(a uint16, v byte) {
+	b.accesses = append(b.accesses, fmt.Sprintf("write %04X %02X", a, v))
+	b.memory[a] = v
+}
+
+func newTestCpu(program ...byte) (*Cpu, *recordingBus) {
+	return newVariantTestCpu(Ricoh2A03, program...)
+}
+
+func newVariantTestCpu(v Variant, program ...byte) (*Cpu, *recordingBus) {
+	bus := new(recordingBus)
+	copy(bus.memory[0x8000:], program)
+
+	c := NewVariantCpu(bus, v)
+	c.ProgramCounter = 0x8000
+
+	return c, bus
+}
+
+func verifyAccesses(bus *recordingBus, expected []string, test *testing.T) {
+	if !reflect.DeepEqual(bus.accesses, expected) {
+		test.Errorf("Bus accesses were\n%q\nexpected\n%q", bus.accesses, expected)
+	}
+}
+
+func TestReadModifyWriteWritesTwice(test *testing.T) {
+	// INC $12F0,X
+	c, bus := newTestCpu(0xFE, 0xF0, 0x12)
+	c.X = 0x20
+	bus.memory[0x1310] = 0x41
+
+	if cycles, _ := c.Step(); cycles != 7 {
+		test.Errorf("INC took %d cycles, expected 7", cycles)
+	}
+
+	verifyAccesses(bus, []string{
+		"read 8000",
+		"read 8001",
+		"read 8002",
+		"read 1210",
+		"read 1310",
+		"write 1310 41",
+		"write 1310 42",
+	}, test)
+}
+
+func TestIndexedReadDummyReads(test *testing.T) {
+	// LDA $2002,X twice, the first stays on the page and the second
+	// crosses it
+	c, bus := newTestCpu(0xBD, 0x02, 0x20, 0xBD, 0xF0, 0x20)
+	c.X = 0x10
+
+	if cycles, _ := c.Step(); cycles != 4 {
+		test.Errorf("LDA took %d cycles without crossing a page, expected 4", cycles)
+	}
+
+	if cycles, _ := c.Step(); cycles != 5 {
+		test.Errorf("LDA took %d cycles crossing a page, expected 5", cycles)
+	}
+
+	verifyAccesses(bus, []string{
+		"read 8000",
+		"read 8001",
+		"read 8002",
+		"read 2012",
+		"read 8003",
+		"read 8004",
+		"read 8005",
+		"read 2000",
+		"read 2100",
+	}, test)
+}
+
+func TestClockStopsMidInstruction(test *testing.T) {
+	// STA $0200
+	c, bus := newTestCpu(0x8D, 0x00, 0x02)
+	c.A = 0x55
+
+	for i := 0; i < 3; i++ {
+		if done, _ := c.Clock(); done {
+			test.Fatalf("STA finished after %d cycles", i+1)
+		}
+	}
+
+	if !c.Executing() || bus.memory[0x0200] != 0x00 {
+		test.Errorf("STA wrote before its last cycle")
+	}
+
+	if done, _ := c.Clock(); !done || c.Executing() {
+		test.Errorf("STA didn't finish on its fourth cycle")
+	}
+
+	if bus.memory[0x0200] != 0x55 {
+		test.Errorf("STA wrote 0x%X, expected 0x55", bus.memory[0x0200])
+	}
+}
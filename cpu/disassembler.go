@@ -0,0 +1,20 @@
+package cpu
+
+// Disassemble returns the instruction at pc in assembler syntax along
+// with its size in bytes. Only the instruction's own bytes are read.
+func Disassemble(bus Bus, pc int) (text string, size int) {
+	i := Lookup(bus.Read(uint16(pc)))
+
+	// Operands are stored low byte first
+	operand := 0
+	for n := i.Size - 1; n > 0; n-- {
+		operand = operand<<8 | int(bus.Read(uint16(pc+n)))
+	}
+
+	text = i.Name
+	if o := i.Operand(operand, pc); o != "" {
+		text += " " + o
+	}
+
+	return text, i.Size
+}
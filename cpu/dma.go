@@ -0,0 +1,99 @@
+package cpu
+
+// The 2A03's DMA unit. A write to $4014 copies a page to OAM through
+// $2004, and the DMC fetches its samples with it. Either one halts the
+// CPU once the instruction in progress is done; the CPU sits out a
+// cycle at a time until both have finished.
+//
+// The unit reads on get cycles and writes on put cycles, which
+// alternate. OAM DMA takes a halt cycle, another if it would start on
+// a put cycle, then 256 reads and writes: 513 or 514 cycles. A DMC
+// fetch during an OAM DMA takes over one of its reads, and the OAM DMA
+// spends another cycle getting back in step.
+//
+// http://wiki.nesdev.com/w/index.php/DMA
+type dma struct {
+	halted bool
+
+	oamActive bool
+	oamPage   int
+	oamIndex  int
+	oamData   byte
+	oamFull   bool
+
+	dmcPending bool
+	dmcDummy   bool
+	dmcAddress int
+	dmcDeliver func(v byte)
+}
+
+// StartOamDma copies page $XX00-$XXFF to OAM, as a write of page to
+// $4014 does
+func (c *Cpu) StartOamDma(page byte) {
+	c.dma.oamActive = true
+	c.dma.oamPage = int(page) << 8
+	c.dma.oamIndex = 0
+	c.dma.oamFull = false
+}
+
+// StartDmcDma fetches the sample byte at address for the DMC, handing
+// it to deliver once it's read
+func (c *Cpu) StartDmcDma(address int, deliver func(v byte)) {
+	c.dma.dmcPending = true
+	c.dma.dmcAddress = address
+	c.dma.dmcDeliver = deliver
+
+	// Unless an OAM DMA already has the CPU halted, the DMC spends a
+	// dummy cycle after the halt
+	c.dma.dmcDummy = !c.dma.halted
+}
+
+// Reports whether a DMA still has cycles to run
+func (c *Cpu) dmaActive() bool {
+	return c.dma.oamActive || c.dma.dmcPending
+}
+
+// Runs a cycle of DMA with the CPU halted, done once there's nothing
+// left to transfer
+func (c *Cpu) clockDma() (done bool) {
+	d := &c.dma
+
+	// The halted CPU keeps repeating the read it was stopped on
+	switch {
+	case !d.halted:
+		d.halted = true
+		c.read(c.ProgramCounter)
+	case d.dmcDummy:
+		d.dmcDummy = false
+		c.read(c.ProgramCounter)
+	case c.Cycles%2 == 0:
+		// Get cycle, the DMC goes first
+		if d.dmcPending {
+			d.dmcPending = false
+			d.dmcDeliver(c.read(d.dmcAddress))
+		} else if d.oamActive && !d.oamFull {
+			d.oamData = c.read(d.oamPage | d.oamIndex)
+			d.oamFull = true
+		} else {
+			c.read(c.ProgramCounter)
+		}
+	default:
+		// Put cycle
+		if d.oamFull {
+			c.write(0x2004, d.oamData)
+			d.oamFull = false
+
+			d.oamIndex++
+			d.oamActive = d.oamIndex < 0x100
+		} else {
+			c.read(c.ProgramCounter)
+		}
+	}
+
+	if !c.dmaActive() {
+		d.halted = false
+		return true
+	}
+
+	return false
+}
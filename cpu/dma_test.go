@@ -0,0 +1,93 @@
+package cpu
+
+import (
+	"testing"
+)
+
+// Runs the CPU until a started DMA has finished, returning the cycles
+// it took
+func runDma(c *Cpu) (cycles int) {
+	for c.dmaActive() {
+		c.Clock()
+		cycles++
+	}
+
+	return
+}
+
+func TestOamDmaTakes513Or514Cycles(test *testing.T) {
+	for _, start := range []uint64{0, 1} {
+		c, bus := newTestCpu(0xEA)
+		c.Cycles = start
+
+		for i := 0; i < 0x100; i++ {
+			bus.memory[0x0300+i] = byte(i)
+		}
+
+		c.StartOamDma(0x03)
+
+		// Halted on a get cycle the reads start straight away,
+		// otherwise they wait a cycle to line up
+		expected := 513 + int(start)
+		if cycles := runDma(c); cycles != expected {
+			test.Errorf("OAM DMA starting on cycle %d took %d cycles, expected %d", start+1, cycles, expected)
+		}
+
+		// Every byte goes through $2004, in order
+		var writes []string
+		for _, a := range bus.accesses {
+			if a[:5] == "write" {
+				writes = append(writes, a)
+			}
+		}
+
+		if len(writes) != 0x100 || writes[0] != "write 2004 00" || writes[0xFF] != "write 2004 FF" {
+			test.Errorf("OAM DMA wrote %d bytes: %q...", len(writes), writes[:2])
+		}
+
+		if c.ProgramCounter != 0x8000 {
+			test.Errorf("OAM DMA moved PC to 0x%04X", c.ProgramCounter)
+		}
+	}
+}
+
+func TestDmcDmaDuringOamDma(test *testing.T) {
+	c, bus := newTestCpu(0xEA)
+	bus.memory[0xC000] = 0x5A
+
+	c.StartOamDma(0x03)
+
+	// Partway through the copy
+	for i := 0; i < 100; i++ {
+		c.Clock()
+	}
+
+	var sample byte
+	c.StartDmcDma(0xC000, func(v byte) {
+		sample = v
+	})
+
+	// The DMC read and getting back in step with OAM DMA
+	if cycles := 100 + runDma(c); cycles != 513+2 {
+		test.Errorf("OAM DMA with a DMC fetch took %d cycles, expected 515", cycles)
+	}
+
+	if sample != 0x5A {
+		test.Errorf("DMC fetched 0x%02X, expected 0x5A", sample)
+	}
+}
+
+func TestDmcDma(test *testing.T) {
+	c, bus := newTestCpu(0xEA)
+	bus.memory[0xC000] = 0x5A
+
+	var sample byte
+	c.StartDmcDma(0xC000, func(v byte) {
+		sample = v
+	})
+
+	// Halt, dummy and read, with a cycle to line up the read
+	if cycles := runDma(c); cycles != 4 || sample != 0x5A {
+		test.Errorf("DMC DMA took %d cycles and fetched 0x%02X", cycles, sample)
+	}
+}
@@ -0,0 +1,115 @@
+package cpu
+
+import (
+	"fmt"
+)
+
+// Mode is how an instruction finds its operand
+type Mode int
+
+const (
+	Implied Mode = iota
+	Accumulator
+	Immediate
+	ZeroPage
+	ZeroPageX
+	ZeroPageY
+	Absolute
+	AbsoluteX
+	AbsoluteY
+	Indirect
+	IndirectX
+	IndirectY
+	Relative
+
+	// 65C02 only
+	ZeroPageIndirect
+	AbsoluteIndirectX
+)
+
+// Instruction bytes taken up by each mode, the opcode included
+var modeSizes = [...]int{
+	Implied:     1,
+	Accumulator: 1,
+	Immediate:   2,
+	ZeroPage:    2,
+	ZeroPageX:   2,
+	ZeroPageY:   2,
+	Absolute:    3,
+	AbsoluteX:   3,
+	AbsoluteY:   3,
+	Indirect:    3,
+	IndirectX:   2,
+	IndirectY:   2,
+	Relative:    2,
+
+	ZeroPageIndirect:  2,
+	AbsoluteIndirectX: 3,
+}
+
+// Access is what an instruction does with the memory its operand
+// points at
+const (
+	AccessNone = iota
+	AccessRead
+	AccessWrite
+	AccessModify
+)
+
+// Instruction describes an opcode. It comes out of the same table the
+// CPU runs instructions from, so the disassembler, tracer and anything
+// else built on it always agree with the CPU.
+type Instruction struct {
+	Name     string
+	Mode     Mode
+	Size     int
+	Access   int
+	Official bool
+
+	// Cycles taken without crossing a page or taking a branch, zero
+	// for KIL
+	Cycles int
+}
+
+// Lookup returns the instruction for opcode on the 2A03
+func Lookup(opcode byte) Instruction {
+	return Ricoh2A03.Lookup(opcode)
+}
+
+// Operand formats the operand of an instruction at pc the way 6502
+// assemblers write it, operand being the value of the bytes after the
+// opcode
+func (i Instruction) Operand(operand int, pc int) string {
+	switch i.Mode {
+	case Accumulator:
+		return "A"
+	case Immediate:
+		return fmt.Sprintf("#$%02X", operand)
+	case ZeroPage:
+		return fmt.Sprintf("$%02X", operand)
+	case ZeroPageX:
+		return fmt.Sprintf("$%02X,X", operand)
+	case ZeroPageY:
+		return fmt.Sprintf("$%02X,Y", operand)
+	case Absolute:
+		return fmt.Sprintf("$%04X", operand)
+	case AbsoluteX:
+		return fmt.Sprintf("$%04X,X", operand)
+	case AbsoluteY:
+		return fmt.Sprintf("$%04X,Y", operand)
+	case Indirect:
+		return fmt.Sprintf("($%04X)", operand)
+	case IndirectX:
+		return fmt.Sprintf("($%02X,X)", operand)
+	case IndirectY:
+		return fmt.Sprintf("($%02X),Y", operand)
+	case Relative:
+		return fmt.Sprintf("$%04X", (pc+2+int(int8(operand)))&0xFFFF)
+	case ZeroPageIndirect:
+		return fmt.Sprintf("($%02X)", operand)
+	case AbsoluteIndirectX:
+		return fmt.Sprintf("($%04X,X)", operand)
+	}
+
+	return ""
+}
@@ -0,0 +1,70 @@
+package cpu
+
+import (
+	"testing"
+)
+
+func TestInstructionsMatchCpu(test *testing.T) {
+	for _, v := range []Variant{Ricoh2A03, Nmos6502, Cmos65C02} {
+		testInstructionsMatchCpu(v, test)
+	}
+}
+
+func testInstructionsMatchCpu(v Variant, test *testing.T) {
+	for op := 0; op < 0x100; op++ {
+		i := v.Lookup(byte(op))
+
+		if i.Name == "" {
+			test.Errorf("%d: %02X is missing from the table", v, op)
+			continue
+		}
+
+		// Branches, jumps, calls and returns don't just move on to
+		// the next instruction
+		if i.Mode == Relative || i.Mode == Indirect || i.Name == "KIL" ||
+			i.Name == "JMP" || i.Name == "JSR" || i.Name == "BRK" || i.Name == "RTI" || i.Name == "RTS" {
+			continue
+		}
+
+		// Zeroed operands and registers keep every access on its page
+		c, _ := newVariantTestCpu(v, byte(op))
+
+		cycles, err := c.Step()
+		if err != nil {
+			test.Fatalf("%d: %02X %s: %s", v, op, i.Name, err.Error())
+		}
+
+		if cycles != i.Cycles {
+			test.Errorf("%d: %02X %s took %d cycles, the table has %d", v, op, i.Name, cycles, i.Cycles)
+		}
+
+		if size := c.ProgramCounter - 0x8000; size != i.Size {
+			test.Errorf("%d: %02X %s is %d bytes long, the table has %d", v, op, i.Name, size, i.Size)
+		}
+	}
+}
+
+func TestDisassemble(test *testing.T) {
+	bus := new(recordingBus)
+
+	program := map[string][]byte{
+		"LDA #$10":    {0xA9, 0x10},
+		"STA $0200,X": {0x9D, 0x00, 0x02},
+		"JMP ($FFFC)": {0x6C, 0xFC, 0xFF},
+		"LDA ($20),Y": {0xB1, 0x20},
+		"ASL A":       {0x0A},
+		"BNE $7FF2":   {0xD0, 0xF0},
+		"NOP $04":     {0x04, 0x04},
+		"KIL":         {0x02},
+		"DCP ($40,X)": {0xC3, 0x40},
+		"SHX $1000,Y": {0x9E, 0x00, 0x10},
+	}
+
+	for expected, bytes := range program {
+		copy(bus.memory[0x8000:], bytes)
+
+		if text, size := Disassemble(bus, 0x8000); text != expected || size != len(bytes) {
+			test.Errorf("Disassembled % X as %q, %d bytes, expected %q", bytes, text, size, expected)
+		}
+	}
+}
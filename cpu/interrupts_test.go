@@ -0,0 +1,154 @@
+package cpu
+
+import (
+	"testing"
+)
+
+// NOPs at $8000 with the vectors pointing at handlers of NOPs
+func newInterruptTestCpu() (*Cpu, *recordingBus) {
+	c, bus := newTestCpu()
+
+	for a := 0x8000; a < 0xC000; a++ {
+		bus.memory[a] = 0xEA
+	}
+
+	bus.memory[0xFFFA], bus.memory[0xFFFB] = 0x00, 0x90
+	bus.memory[0xFFFC], bus.memory[0xFFFD] = 0x00, 0xA0
+	bus.memory[0xFFFE], bus.memory[0xFFFF] = 0x00, 0xB0
+
+	return c, bus
+}
+
+func TestIrqLineIsShared(test *testing.T) {
+	c, _ := newInterruptTestCpu()
+	c.P = 0x20
+
+	c.AssertIrq(IrqMapper)
+	c.AssertIrq(IrqDmc)
+	c.AcknowledgeIrq(IrqMapper)
+
+	c.Step()
+	if cycles, _ := c.Step(); cycles != 7 || c.ProgramCounter != 0xB000 {
+		test.Fatalf("IRQ wasn't taken with the DMC still holding the line")
+	}
+
+	// The handler runs with IRQs masked, clearing I again takes
+	// another after the next instruction until the last source lets go
+	c.P = 0x20
+	c.Step()
+	c.Step()
+	if c.ProgramCounter != 0xB000 || c.StackPointer != 0xF7 {
+		test.Errorf("IRQ wasn't taken again while the line was held")
+	}
+
+	c.AcknowledgeIrq(IrqDmc)
+	c.P = 0x20
+	c.Step()
+	c.Step()
+	if c.StackPointer != 0xF7 {
+		test.Errorf("IRQ was taken after every source acknowledged it")
+	}
+}
+
+func TestTakenBranchDelaysIrq(test *testing.T) {
+	c, bus := newInterruptTestCpu()
+	c.P = 0x20
+
+	// BCC +0, taken without crossing a page
+	bus.memory[0x8000], bus.memory[0x8001] = 0x90, 0x00
+
+	c.Clock()
+	c.AssertIrq(IrqMapper)
+	c.Clock()
+	c.Clock()
+
+	if c.Step(); c.ProgramCounter != 0x8003 {
+		test.Errorf("IRQ was taken straight after the branch")
+	}
+
+	if c.Step(); c.ProgramCounter != 0xB000 {
+		test.Errorf("IRQ wasn't taken after the instruction following the branch")
+	}
+}
+
+func TestNmiIsEdgeTriggered(test *testing.T) {
+	c, _ := newInterruptTestCpu()
+
+	c.SetNmi(true)
+	c.Step()
+
+	if c.Step(); c.ProgramCounter != 0x9000 {
+		test.Fatalf("NMI wasn't taken, PC is 0x%04X", c.ProgramCounter)
+	}
+
+	// Holding the line doesn't make another
+	c.Step()
+	c.Step()
+	if c.StackPointer != 0xFA {
+		test.Errorf("NMI was taken again without another edge")
+	}
+
+	c.SetNmi(false)
+	c.Step()
+	c.SetNmi(true)
+	c.Step()
+	if c.Step(); c.StackPointer != 0xF7 {
+		test.Errorf("NMI wasn't taken on the second edge")
+	}
+}
+
+func TestNmiHijacksBrk(test *testing.T) {
+	// BRK
+	c, bus := newTestCpu(0x00)
+	bus.memory[0xFFFA], bus.memory[0xFFFB] = 0x00, 0x90
+	bus.memory[0xFFFE], bus.memory[0xFFFF] = 0x00, 0xB0
+
+	c.Clock()
+	c.Clock()
+	c.SetNmi(true)
+
+	if cycles, _ := c.Step(); cycles != 5 {
+		test.Errorf("BRK took %d cycles, expected 7", cycles+2)
+	}
+
+	if c.ProgramCounter != 0x9000 {
+		test.Errorf("BRK jumped to 0x%04X, expected the NMI handler", c.ProgramCounter)
+	}
+
+	if p := bus.memory[0x100+int(c.StackPointer)+1]; p&0x10 == 0 {
+		test.Errorf("Hijacked BRK pushed P without the B flag: 0x%02X", p)
+	}
+
+	// The NMI was handled by the BRK
+	c.Step()
+	if c.ProgramCounter == 0x9000 {
+		test.Errorf("NMI was taken a second time")
+	}
+}
+
+func TestResetSequence(test *testing.T) {
+	c, bus := newInterruptTestCpu()
+	c.P = 0x00
+
+	c.Reset()
+	bus.accesses = nil
+
+	if cycles, _ := c.Step(); cycles != 7 {
+		test.Errorf("Reset took %d cycles, expected 7", cycles)
+	}
+
+	if c.ProgramCounter != 0xA000 || c.StackPointer != 0xFA || !c.getIrqDisable() {
+		test.Errorf("Reset left PC 0x%04X, S 0x%02X, P 0x%02X", c.ProgramCounter, c.StackPointer, c.P)
+	}
+
+	// The stack is read instead of written
+	verifyAccesses(bus, []string{
+		"read 8000",
+		"read 8000",
+		"read 01FD",
+		"read 01FC",
+		"read 01FB",
+		"read FFFC",
+		"read FFFD",
+	}, test)
+}
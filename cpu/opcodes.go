@@ -0,0 +1,344 @@
+package cpu
+
+// Every opcode the 2A03 runs, official and unofficial, with the
+// description the disassembler and tracer use
+var opcodes = [0x100]opcode{
+	// ADC
+	0x69: readOp("ADC", Immediate, (*Cpu).Adc),
+	0x65: readOp("ADC", ZeroPage, (*Cpu).Adc),
+	0x75: readOp("ADC", ZeroPageX, (*Cpu).Adc),
+	0x6D: readOp("ADC", Absolute, (*Cpu).Adc),
+	0x7D: readOp("ADC", AbsoluteX, (*Cpu).Adc),
+	0x79: readOp("ADC", AbsoluteY, (*Cpu).Adc),
+	0x61: readOp("ADC", IndirectX, (*Cpu).Adc),
+	0x71: readOp("ADC", IndirectY, (*Cpu).Adc),
+	// LDA
+	0xA9: readOp("LDA", Immediate, (*Cpu).Lda),
+	0xA5: readOp("LDA", ZeroPage, (*Cpu).Lda),
+	0xB5: readOp("LDA", ZeroPageX, (*Cpu).Lda),
+	0xAD: readOp("LDA", Absolute, (*Cpu).Lda),
+	0xBD: readOp("LDA", AbsoluteX, (*Cpu).Lda),
+	0xB9: readOp("LDA", AbsoluteY, (*Cpu).Lda),
+	0xA1: readOp("LDA", IndirectX, (*Cpu).Lda),
+	0xB1: readOp("LDA", IndirectY, (*Cpu).Lda),
+	// LDX
+	0xA2: readOp("LDX", Immediate, (*Cpu).Ldx),
+	0xA6: readOp("LDX", ZeroPage, (*Cpu).Ldx),
+	0xB6: readOp("LDX", ZeroPageY, (*Cpu).Ldx),
+	0xAE: readOp("LDX", Absolute, (*Cpu).Ldx),
+	0xBE: readOp("LDX", AbsoluteY, (*Cpu).Ldx),
+	// LDY
+	0xA0: readOp("LDY", Immediate, (*Cpu).Ldy),
+	0xA4: readOp("LDY", ZeroPage, (*Cpu).Ldy),
+	0xB4: readOp("LDY", ZeroPageX, (*Cpu).Ldy),
+	0xAC: readOp("LDY", Absolute, (*Cpu).Ldy),
+	0xBC: readOp("LDY", AbsoluteX, (*Cpu).Ldy),
+	// STA
+	0x85: writeOp("STA", ZeroPage, (*Cpu).Sta),
+	0x95: writeOp("STA", ZeroPageX, (*Cpu).Sta),
+	0x8D: writeOp("STA", Absolute, (*Cpu).Sta),
+	0x9D: writeOp("STA", AbsoluteX, (*Cpu).Sta),
+	0x99: writeOp("STA", AbsoluteY, (*Cpu).Sta),
+	0x81: writeOp("STA", IndirectX, (*Cpu).Sta),
+	0x91: writeOp("STA", IndirectY, (*Cpu).Sta),
+	// STX
+	0x86: writeOp("STX", ZeroPage, (*Cpu).Stx),
+	0x96: writeOp("STX", ZeroPageY, (*Cpu).Stx),
+	0x8E: writeOp("STX", Absolute, (*Cpu).Stx),
+	// STY
+	0x84: writeOp("STY", ZeroPage, (*Cpu).Sty),
+	0x94: writeOp("STY", ZeroPageX, (*Cpu).Sty),
+	0x8C: writeOp("STY", Absolute, (*Cpu).Sty),
+	// JMP
+	0x4C: controlOp("JMP", Absolute, jmpAbsoluteCycles),
+	0x6C: controlOp("JMP", Indirect, jmpIndirectCycles),
+	// JSR
+	0x20: controlOp("JSR", Absolute, jsrCycles),
+	// TAX
+	0xAA: impliedOp("TAX", (*Cpu).Tax),
+	// TXA
+	0x8A: impliedOp("TXA", (*Cpu).Txa),
+	// DEX
+	0xCA: impliedOp("DEX", (*Cpu).Dex),
+	// INX
+	0xE8: impliedOp("INX", (*Cpu).Inx),
+	// TAY
+	0xA8: impliedOp("TAY", (*Cpu).Tay),
+	// TYA
+	0x98: impliedOp("TYA", (*Cpu).Tya),
+	// DEY
+	0x88: impliedOp("DEY", (*Cpu).Dey),
+	// INY
+	0xC8: impliedOp("INY", (*Cpu).Iny),
+	// BPL
+	0x10: branchOp("BPL", (*Cpu).Bpl),
+	// BMI
+	0x30: branchOp("BMI", (*Cpu).Bmi),
+	// BVC
+	0x50: branchOp("BVC", (*Cpu).Bvc),
+	// BVS
+	0x70: branchOp("BVS", (*Cpu).Bvs),
+	// BCC
+	0x90: branchOp("BCC", (*Cpu).Bcc),
+	// BCS
+	0xB0: branchOp("BCS", (*Cpu).Bcs),
+	// BNE
+	0xD0: branchOp("BNE", (*Cpu).Bne),
+	// BEQ
+	0xF0: branchOp("BEQ", (*Cpu).Beq),
+	// CMP
+	0xC9: readOp("CMP", Immediate, (*Cpu).Cmp),
+	0xC5: readOp("CMP", ZeroPage, (*Cpu).Cmp),
+	0xD5: readOp("CMP", ZeroPageX, (*Cpu).Cmp),
+	0xCD: readOp("CMP", Absolute, (*Cpu).Cmp),
+	0xDD: readOp("CMP", AbsoluteX, (*Cpu).Cmp),
+	0xD9: readOp("CMP", AbsoluteY, (*Cpu).Cmp),
+	0xC1: readOp("CMP", IndirectX, (*Cpu).Cmp),
+	0xD1: readOp("CMP", IndirectY, (*Cpu).Cmp),
+	// CPX
+	0xE0: readOp("CPX", Immediate, (*Cpu).Cpx),
+	0xE4: readOp("CPX", ZeroPage, (*Cpu).Cpx),
+	0xEC: readOp("CPX", Absolute, (*Cpu).Cpx),
+	// CPY
+	0xC0: readOp("CPY", Immediate, (*Cpu).Cpy),
+	0xC4: readOp("CPY", ZeroPage, (*Cpu).Cpy),
+	0xCC: readOp("CPY", Absolute, (*Cpu).Cpy),
+	// SBC
+	0xE9: readOp("SBC", Immediate, (*Cpu).Sbc),
+	0xE5: readOp("SBC", ZeroPage, (*Cpu).Sbc),
+	0xF5: readOp("SBC", ZeroPageX, (*Cpu).Sbc),
+	0xED: readOp("SBC", Absolute, (*Cpu).Sbc),
+	0xFD: readOp("SBC", AbsoluteX, (*Cpu).Sbc),
+	0xF9: readOp("SBC", AbsoluteY, (*Cpu).Sbc),
+	0xE1: readOp("SBC", IndirectX, (*Cpu).Sbc),
+	0xF1: readOp("SBC", IndirectY, (*Cpu).Sbc),
+	// CLC
+	0x18: impliedOp("CLC", (*Cpu).Clc),
+	// SEC
+	0x38: impliedOp("SEC", (*Cpu).Sec),
+	// CLI
+	0x58: impliedOp("CLI", (*Cpu).Cli),
+	// SEI
+	0x78: impliedOp("SEI", (*Cpu).Sei),
+	// CLV
+	0xB8: impliedOp("CLV", (*Cpu).Clv),
+	// CLD
+	0xD8: impliedOp("CLD", (*Cpu).Cld),
+	// SED
+	0xF8: impliedOp("SED", (*Cpu).Sed),
+	// TXS
+	0x9A: impliedOp("TXS", (*Cpu).Txs),
+	// TSX
+	0xBA: impliedOp("TSX", (*Cpu).Tsx),
+	// PHA
+	0x48: pushOp("PHA", (*Cpu).Pha),
+	// PLA
+	0x68: pullOp("PLA", (*Cpu).Pla),
+	// PHP
+	0x08: pushOp("PHP", (*Cpu).Php),
+	// PLP
+	0x28: pullOp("PLP", (*Cpu).Plp),
+	// AND
+	0x29: readOp("AND", Immediate, (*Cpu).And),
+	0x25: readOp("AND", ZeroPage, (*Cpu).And),
+	0x35: readOp("AND", ZeroPageX, (*Cpu).And),
+	0x2D: readOp("AND", Absolute, (*Cpu).And),
+	0x3D: readOp("AND", AbsoluteX, (*Cpu).And),
+	0x39: readOp("AND", AbsoluteY, (*Cpu).And),
+	0x21: readOp("AND", IndirectX, (*Cpu).And),
+	0x31: readOp("AND", IndirectY, (*Cpu).And),
+	// ORA
+	0x09: readOp("ORA", Immediate, (*Cpu).Ora),
+	0x05: readOp("ORA", ZeroPage, (*Cpu).Ora),
+	0x15: readOp("ORA", ZeroPageX, (*Cpu).Ora),
+	0x0D: readOp("ORA", Absolute, (*Cpu).Ora),
+	0x1D: readOp("ORA", AbsoluteX, (*Cpu).Ora),
+	0x19: readOp("ORA", AbsoluteY, (*Cpu).Ora),
+	0x01: readOp("ORA", IndirectX, (*Cpu).Ora),
+	0x11: readOp("ORA", IndirectY, (*Cpu).Ora),
+	// EOR
+	0x49: readOp("EOR", Immediate, (*Cpu).Eor),
+	0x45: readOp("EOR", ZeroPage, (*Cpu).Eor),
+	0x55: readOp("EOR", ZeroPageX, (*Cpu).Eor),
+	0x4D: readOp("EOR", Absolute, (*Cpu).Eor),
+	0x5D: readOp("EOR", AbsoluteX, (*Cpu).Eor),
+	0x59: readOp("EOR", AbsoluteY, (*Cpu).Eor),
+	0x41: readOp("EOR", IndirectX, (*Cpu).Eor),
+	0x51: readOp("EOR", IndirectY, (*Cpu).Eor),
+	// DEC
+	0xC6: modifyOp("DEC", ZeroPage, (*Cpu).Dec),
+	0xD6: modifyOp("DEC", ZeroPageX, (*Cpu).Dec),
+	0xCE: modifyOp("DEC", Absolute, (*Cpu).Dec),
+	0xDE: modifyOp("DEC", AbsoluteX, (*Cpu).Dec),
+	// INC
+	0xE6: modifyOp("INC", ZeroPage, (*Cpu).Inc),
+	0xF6: modifyOp("INC", ZeroPageX, (*Cpu).Inc),
+	0xEE: modifyOp("INC", Absolute, (*Cpu).Inc),
+	0xFE: modifyOp("INC", AbsoluteX, (*Cpu).Inc),
+	// BRK
+	0x00: controlOp("BRK", Implied, brkCycles),
+	// RTI
+	0x40: controlOp("RTI", Implied, rtiCycles),
+	// RTS
+	0x60: controlOp("RTS", Implied, rtsCycles),
+	// NOP
+	0xEA: impliedOp("NOP", (*Cpu).Nop),
+	// LSR
+	0x4A: accumulatorOp("LSR", (*Cpu).LsrAcc),
+	0x46: modifyOp("LSR", ZeroPage, (*Cpu).Lsr),
+	0x56: modifyOp("LSR", ZeroPageX, (*Cpu).Lsr),
+	0x4E: modifyOp("LSR", Absolute, (*Cpu).Lsr),
+	0x5E: modifyOp("LSR", AbsoluteX, (*Cpu).Lsr),
+	// ASL
+	0x0A: accumulatorOp("ASL", (*Cpu).AslAcc),
+	0x06: modifyOp("ASL", ZeroPage, (*Cpu).Asl),
+	0x16: modifyOp("ASL", ZeroPageX, (*Cpu).Asl),
+	0x0E: modifyOp("ASL", Absolute, (*Cpu).Asl),
+	0x1E: modifyOp("ASL", AbsoluteX, (*Cpu).Asl),
+	// ROL
+	0x2A: accumulatorOp("ROL", (*Cpu).RolAcc),
+	0x26: modifyOp("ROL", ZeroPage, (*Cpu).Rol),
+	0x36: modifyOp("ROL", ZeroPageX, (*Cpu).Rol),
+	0x2E: modifyOp("ROL", Absolute, (*Cpu).Rol),
+	0x3E: modifyOp("ROL", AbsoluteX, (*Cpu).Rol),
+	// ROR
+	0x6A: accumulatorOp("ROR", (*Cpu).RorAcc),
+	0x66: modifyOp("ROR", ZeroPage, (*Cpu).Ror),
+	0x76: modifyOp("ROR", ZeroPageX, (*Cpu).Ror),
+	0x6E: modifyOp("ROR", Absolute, (*Cpu).Ror),
+	0x7E: modifyOp("ROR", AbsoluteX, (*Cpu).Ror),
+	// BIT
+	0x24: readOp("BIT", ZeroPage, (*Cpu).Bit),
+	0x2C: readOp("BIT", Absolute, (*Cpu).Bit),
+
+	// Unofficial opcodes
+
+	// NOP
+	0x1A: unofficial(impliedOp("NOP", (*Cpu).Nop)),
+	0x3A: unofficial(impliedOp("NOP", (*Cpu).Nop)),
+	0x5A: unofficial(impliedOp("NOP", (*Cpu).Nop)),
+	0x7A: unofficial(impliedOp("NOP", (*Cpu).Nop)),
+	0xDA: unofficial(impliedOp("NOP", (*Cpu).Nop)),
+	0xFA: unofficial(impliedOp("NOP", (*Cpu).Nop)),
+	// NOP, the ones with an operand read it (IGN)
+	0x80: unofficial(readOp("NOP", Immediate, (*Cpu).Ign)),
+	0x82: unofficial(readOp("NOP", Immediate, (*Cpu).Ign)),
+	0x89: unofficial(readOp("NOP", Immediate, (*Cpu).Ign)),
+	0xC2: unofficial(readOp("NOP", Immediate, (*Cpu).Ign)),
+	0xE2: unofficial(readOp("NOP", Immediate, (*Cpu).Ign)),
+	0x04: unofficial(readOp("NOP", ZeroPage, (*Cpu).Ign)),
+	0x44: unofficial(readOp("NOP", ZeroPage, (*Cpu).Ign)),
+	0x64: unofficial(readOp("NOP", ZeroPage, (*Cpu).Ign)),
+	0x14: unofficial(readOp("NOP", ZeroPageX, (*Cpu).Ign)),
+	0x34: unofficial(readOp("NOP", ZeroPageX, (*Cpu).Ign)),
+	0x54: unofficial(readOp("NOP", ZeroPageX, (*Cpu).Ign)),
+	0x74: unofficial(readOp("NOP", ZeroPageX, (*Cpu).Ign)),
+	0xD4: unofficial(readOp("NOP", ZeroPageX, (*Cpu).Ign)),
+	0xF4: unofficial(readOp("NOP", ZeroPageX, (*Cpu).Ign)),
+	0x0C: unofficial(readOp("NOP", Absolute, (*Cpu).Ign)),
+	0x1C: unofficial(readOp("NOP", AbsoluteX, (*Cpu).Ign)),
+	0x3C: unofficial(readOp("NOP", AbsoluteX, (*Cpu).Ign)),
+	0x5C: unofficial(readOp("NOP", AbsoluteX, (*Cpu).Ign)),
+	0x7C: unofficial(readOp("NOP", AbsoluteX, (*Cpu).Ign)),
+	0xDC: unofficial(readOp("NOP", AbsoluteX, (*Cpu).Ign)),
+	0xFC: unofficial(readOp("NOP", AbsoluteX, (*Cpu).Ign)),
+	// LAX
+	0xA7: unofficial(readOp("LAX", ZeroPage, (*Cpu).Lax)),
+	0xB7: unofficial(readOp("LAX", ZeroPageY, (*Cpu).Lax)),
+	0xAF: unofficial(readOp("LAX", Absolute, (*Cpu).Lax)),
+	0xBF: unofficial(readOp("LAX", AbsoluteY, (*Cpu).Lax)),
+	0xA3: unofficial(readOp("LAX", IndirectX, (*Cpu).Lax)),
+	0xB3: unofficial(readOp("LAX", IndirectY, (*Cpu).Lax)),
+	// SAX
+	0x87: unofficial(writeOp("SAX", ZeroPage, (*Cpu).Sax)),
+	0x97: unofficial(writeOp("SAX", ZeroPageY, (*Cpu).Sax)),
+	0x8F: unofficial(writeOp("SAX", Absolute, (*Cpu).Sax)),
+	0x83: unofficial(writeOp("SAX", IndirectX, (*Cpu).Sax)),
+	// SBC
+	0xEB: unofficial(readOp("SBC", Immediate, (*Cpu).Sbc)),
+	// DCP
+	0xC7: unofficial(modifyOp("DCP", ZeroPage, (*Cpu).Dcp)),
+	0xD7: unofficial(modifyOp("DCP", ZeroPageX, (*Cpu).Dcp)),
+	0xCF: unofficial(modifyOp("DCP", Absolute, (*Cpu).Dcp)),
+	0xDF: unofficial(modifyOp("DCP", AbsoluteX, (*Cpu).Dcp)),
+	0xDB: unofficial(modifyOp("DCP", AbsoluteY, (*Cpu).Dcp)),
+	0xC3: unofficial(modifyOp("DCP", IndirectX, (*Cpu).Dcp)),
+	0xD3: unofficial(modifyOp("DCP", IndirectY, (*Cpu).Dcp)),
+	// ISB
+	0xE7: unofficial(modifyOp("ISB", ZeroPage, (*Cpu).Isb)),
+	0xF7: unofficial(modifyOp("ISB", ZeroPageX, (*Cpu).Isb)),
+	0xEF: unofficial(modifyOp("ISB", Absolute, (*Cpu).Isb)),
+	0xFF: unofficial(modifyOp("ISB", AbsoluteX, (*Cpu).Isb)),
+	0xFB: unofficial(modifyOp("ISB", AbsoluteY, (*Cpu).Isb)),
+	0xE3: unofficial(modifyOp("ISB", IndirectX, (*Cpu).Isb)),
+	0xF3: unofficial(modifyOp("ISB", IndirectY, (*Cpu).Isb)),
+	// SLO
+	0x07: unofficial(modifyOp("SLO", ZeroPage, (*Cpu).Slo)),
+	0x17: unofficial(modifyOp("SLO", ZeroPageX, (*Cpu).Slo)),
+	0x0F: unofficial(modifyOp("SLO", Absolute, (*Cpu).Slo)),
+	0x1F: unofficial(modifyOp("SLO", AbsoluteX, (*Cpu).Slo)),
+	0x1B: unofficial(modifyOp("SLO", AbsoluteY, (*Cpu).Slo)),
+	0x03: unofficial(modifyOp("SLO", IndirectX, (*Cpu).Slo)),
+	0x13: unofficial(modifyOp("SLO", IndirectY, (*Cpu).Slo)),
+	// RLA
+	0x27: unofficial(modifyOp("RLA", ZeroPage, (*Cpu).Rla)),
+	0x37: unofficial(modifyOp("RLA", ZeroPageX, (*Cpu).Rla)),
+	0x2F: unofficial(modifyOp("RLA", Absolute, (*Cpu).Rla)),
+	0x3F: unofficial(modifyOp("RLA", AbsoluteX, (*Cpu).Rla)),
+	0x3B: unofficial(modifyOp("RLA", AbsoluteY, (*Cpu).Rla)),
+	0x23: unofficial(modifyOp("RLA", IndirectX, (*Cpu).Rla)),
+	0x33: unofficial(modifyOp("RLA", IndirectY, (*Cpu).Rla)),
+	// SRE
+	0x47: unofficial(modifyOp("SRE", ZeroPage, (*Cpu).Sre)),
+	0x57: unofficial(modifyOp("SRE", ZeroPageX, (*Cpu).Sre)),
+	0x4F: unofficial(modifyOp("SRE", Absolute, (*Cpu).Sre)),
+	0x5F: unofficial(modifyOp("SRE", AbsoluteX, (*Cpu).Sre)),
+	0x5B: unofficial(modifyOp("SRE", AbsoluteY, (*Cpu).Sre)),
+	0x43: unofficial(modifyOp("SRE", IndirectX, (*Cpu).Sre)),
+	0x53: unofficial(modifyOp("SRE", IndirectY, (*Cpu).Sre)),
+	// RRA
+	0x67: unofficial(modifyOp("RRA", ZeroPage, (*Cpu).Rra)),
+	0x77: unofficial(modifyOp("RRA", ZeroPageX, (*Cpu).Rra)),
+	0x6F: unofficial(modifyOp("RRA", Absolute, (*Cpu).Rra)),
+	0x7F: unofficial(modifyOp("RRA", AbsoluteX, (*Cpu).Rra)),
+	0x7B: unofficial(modifyOp("RRA", AbsoluteY, (*Cpu).Rra)),
+	0x63: unofficial(modifyOp("RRA", IndirectX, (*Cpu).Rra)),
+	0x73: unofficial(modifyOp("RRA", IndirectY, (*Cpu).Rra)),
+	// ANC
+	0x0B: unofficial(readOp("ANC", Immediate, (*Cpu).Anc)),
+	0x2B: unofficial(readOp("ANC", Immediate, (*Cpu).Anc)),
+	// ALR
+	0x4B: unofficial(readOp("ALR", Immediate, (*Cpu).Alr)),
+	// ARR
+	0x6B: unofficial(readOp("ARR", Immediate, (*Cpu).Arr)),
+	// AXS
+	0xCB: unofficial(readOp("AXS", Immediate, (*Cpu).Axs)),
+	// XAA
+	0x8B: unofficial(readOp("XAA", Immediate, (*Cpu).Xaa)),
+	// LXA
+	0xAB: unofficial(readOp("LXA", Immediate, (*Cpu).Lxa)),
+	// LAS
+	0xBB: unofficial(readOp("LAS", AbsoluteY, (*Cpu).Las)),
+	// SHA
+	0x9F: unofficial(writeOp("SHA", AbsoluteY, (*Cpu).Sha)),
+	0x93: unofficial(writeOp("SHA", IndirectY, (*Cpu).Sha)),
+	// SHX
+	0x9E: unofficial(writeOp("SHX", AbsoluteY, (*Cpu).Shx)),
+	// SHY
+	0x9C: unofficial(writeOp("SHY", AbsoluteX, (*Cpu).Shy)),
+	// TAS
+	0x9B: unofficial(writeOp("TAS", AbsoluteY, (*Cpu).Tas)),
+	// KIL
+	0x02: unofficial(jamOp()),
+	0x12: unofficial(jamOp()),
+	0x22: unofficial(jamOp()),
+	0x32: unofficial(jamOp()),
+	0x42: unofficial(jamOp()),
+	0x52: unofficial(jamOp()),
+	0x62: unofficial(jamOp()),
+	0x72: unofficial(jamOp()),
+	0x92: unofficial(jamOp()),
+	0xB2: unofficial(jamOp()),
+	0xD2: unofficial(jamOp()),
+	0xF2: unofficial(jamOp()),
+}
@@ -0,0 +1,186 @@
+package cpu
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Runs every opcode against the single step tests from
+//
+// https://github.com/SingleStepTests/65x02
+//
+// Each opcode has a file of vectors giving the registers and RAM before
+// and after the instruction, and every bus access it makes on the way.
+// They aren't checked in; clone the repository to
+// test_roms/ProcessorTests or point PROCESSOR_TESTS at it. Opcodes with
+// no file are skipped.
+const processorTestsDir = "../test_roms/ProcessorTests"
+
+// Where each variant's tests are under the repository. The Synertek
+// 65C02 is the one without the Rockwell and WDC extensions.
+var processorTestVariants = map[Variant]string{
+	Ricoh2A03: "nes6502",
+	Nmos6502:  "6502",
+	Cmos65C02: "synertek65c02",
+}
+
+// Failures reported for an opcode before moving on to the next
+const processorTestMaxFailures = 5
+
+type processorRegisters struct {
+	PC int  `json:"pc"`
+	S  byte `json:"s"`
+	A  byte `json:"a"`
+	X  byte `json:"x"`
+	Y  byte `json:"y"`
+	P  byte `json:"p"`
+}
+
+func (r processorRegisters) String() string {
+	return fmt.Sprintf("PC=%04X S=%02X A=%02X X=%02X Y=%02X P=%02X", r.PC, r.S, r.A, r.X, r.Y, r.P)
+}
+
+type processorState struct {
+	processorRegisters
+	RAM [][2]int `json:"ram"`
+}
+
+type processorTest struct {
+	Name    string          `json:"name"`
+	Initial processorState  `json:"initial"`
+	Final   processorState  `json:"final"`
+	Cycles  [][]interface{} `json:"cycles"`
+}
+
+// A flat 64k bus, only the addresses a vector sets are backed
+type processorTestBus struct {
+	memory   map[uint16]byte
+	accesses []string
+}
+
+func (b *processorTestBus) Read(a uint16) byte {
+	v := b.memory[a]
+	b.accesses = append(b.accesses, fmt.Sprintf("read %04X %02X", a, v))
+
+	return v
+}
+
+func (b *processorTestBus) Write(a uint16, v byte) {
+	b.accesses = append(b.accesses, fmt.Sprintf("write %04X %02X", a, v))
+	b.memory[a] = v
+}
+
+func TestProcessorTests(test *testing.T) {
+	root := os.Getenv("PROCESSOR_TESTS")
+	if root == "" {
+		root = processorTestsDir
+	}
+
+	found := false
+
+	for v, dir := range processorTestVariants {
+		dir = filepath.Join(root, dir, "v1")
+
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+
+		found = true
+
+		for op := 0; op < 0x100; op++ {
+			path := filepath.Join(dir, fmt.Sprintf("%02x.json", op))
+
+			test.Run(fmt.Sprintf("%s/%02X", processorTestVariants[v], op), func(test *testing.T) {
+				runProcessorTests(v, byte(op), path, test)
+			})
+		}
+	}
+
+	if !found {
+		test.Skipf("no single step tests in %s", root)
+	}
+}
+
+func runProcessorTests(v Variant, op byte, path string, test *testing.T) {
+	if v.Lookup(op).Name == "KIL" {
+		test.Skip("the CPU stops running on KIL")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		test.Skip("no test file")
+	} else if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	var vectors []processorTest
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		test.Fatalf("%s: %s", path, err.Error())
+	}
+
+	failures := 0
+
+	for _, t := range vectors {
+		if msg := runProcessorTest(v, &t); msg != "" {
+			test.Errorf("%s: %s", t.Name, msg)
+
+			if failures++; failures == processorTestMaxFailures {
+				test.Fatalf("Giving up on %02X", op)
+			}
+		}
+	}
+}
+
+// Runs a single vector, returning what went wrong or nothing when it
+// passes
+func runProcessorTest(v Variant, t *processorTest) string {
+	bus := &processorTestBus{memory: make(map[uint16]byte)}
+
+	for _, m := range t.Initial.RAM {
+		bus.memory[uint16(m[0])] = byte(m[1])
+	}
+
+	c := NewVariantCpu(bus, v)
+	c.ProgramCounter = t.Initial.PC
+	c.StackPointer = t.Initial.S
+	c.A = t.Initial.A
+	c.X = t.Initial.X
+	c.Y = t.Initial.Y
+	c.P = t.Initial.P
+
+	if _, err := c.Step(); err != nil {
+		return err.Error()
+	}
+
+	// B and the unused bit aren't flip-flops in P, only what's pushed
+	// shows them
+	got := processorRegisters{c.ProgramCounter, c.StackPointer, c.A, c.X, c.Y, c.P | 0x30}
+	expected := t.Final.processorRegisters
+	expected.P |= 0x30
+
+	if got != expected {
+		return fmt.Sprintf("registers were %s, expected %s", got, expected)
+	}
+
+	for _, m := range t.Final.RAM {
+		if a, v := uint16(m[0]), byte(m[1]); bus.memory[a] != v {
+			return fmt.Sprintf("%04X was %02X, expected %02X", a, bus.memory[a], v)
+		}
+	}
+
+	var cycles []string
+	for _, cycle := range t.Cycles {
+		cycles = append(cycles, fmt.Sprintf("%s %04X %02X", cycle[2], int(cycle[0].(float64)), int(cycle[1].(float64))))
+	}
+
+	if !reflect.DeepEqual(bus.accesses, cycles) {
+		return fmt.Sprintf("bus accesses were\n%q\nexpected\n%q", bus.accesses, cycles)
+	}
+
+	return ""
+}
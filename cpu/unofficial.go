@@ -0,0 +1,170 @@
+package cpu
+
+// Unofficial opcodes. Most are two official instructions sharing one
+// opcode, and are built out of them here.
+//
+// http://www.oxyron.de/html/opcodes02.html
+// http://nesdev.com/undocumented_opcodes.txt
+
+// Values the unstable XAA and LXA OR into A before masking. They vary
+// between chips and with temperature; $EE is what most documentation
+// settles on for XAA, the 2A03's LXA behaves as if it were $FF.
+const (
+	xaaMagic = 0xEE
+	lxaMagic = 0xFF
+)
+
+// IGN, the multi-byte NOPs, read their operand and throw it away
+func (c *Cpu) Ign(val byte) {
+}
+
+func (c *Cpu) Lax(val byte) {
+	c.Lda(val)
+	c.X = c.A
+}
+
+func (c *Cpu) Sax() byte {
+	return c.A & c.X
+}
+
+func (c *Cpu) Dcp(val byte) byte {
+	val = c.Dec(val)
+	c.Cmp(val)
+
+	return val
+}
+
+func (c *Cpu) Isb(val byte) byte {
+	val = c.Inc(val)
+	c.Sbc(val)
+
+	return val
+}
+
+func (c *Cpu) Slo(val byte) byte {
+	val = c.Asl(val)
+	c.Ora(val)
+
+	return val
+}
+
+func (c *Cpu) Rla(val byte) byte {
+	val = c.Rol(val)
+	c.And(val)
+
+	return val
+}
+
+func (c *Cpu) Sre(val byte) byte {
+	val = c.Lsr(val)
+	c.Eor(val)
+
+	return val
+}
+
+func (c *Cpu) Rra(val byte) byte {
+	val = c.Ror(val)
+	c.Adc(val)
+
+	return val
+}
+
+func (c *Cpu) Anc(val byte) {
+	c.And(val)
+
+	if c.A&0x80 > 0 {
+		c.setCarry()
+	} else {
+		c.clearCarry()
+	}
+}
+
+func (c *Cpu) Alr(val byte) {
+	c.And(val)
+	c.LsrAcc()
+}
+
+func (c *Cpu) Arr(val byte) {
+	c.And(val)
+	c.RorAcc()
+
+	// Carry comes from bit 6 of the result and overflow from
+	// bit 6 XOR bit 5
+	if c.A&0x40 > 0 {
+		c.setCarry()
+	} else {
+		c.clearCarry()
+	}
+
+	if (c.A>>6)&0x1 != (c.A>>5)&0x1 {
+		c.setOverflow()
+	} else {
+		c.clearOverflow()
+	}
+}
+
+func (c *Cpu) Axs(val byte) {
+	c.Compare(c.A&c.X, val)
+	c.X = (c.A & c.X) - val
+}
+
+func (c *Cpu) Las(val byte) {
+	val &= c.StackPointer
+
+	c.A = val
+	c.X = val
+	c.StackPointer = val
+
+	c.testAndSetNegative(val)
+	c.testAndSetZero(val)
+}
+
+func (c *Cpu) Xaa(val byte) {
+	c.A = (c.A | xaaMagic) & c.X & val
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+}
+
+func (c *Cpu) Lxa(val byte) {
+	c.A = (c.A | lxaMagic) & val
+	c.X = c.A
+
+	c.testAndSetNegative(c.A)
+	c.testAndSetZero(c.A)
+}
+
+// SHA, SHX, SHY and TAS store a register ANDed with the high byte of
+// the base address plus one. When indexing crosses a page that value
+// replaces the high byte of the address as well.
+func (c *Cpu) unstableStore(v byte) byte {
+	high := byte(c.address >> 8)
+	if !c.carry {
+		high++
+	}
+
+	v &= high
+
+	if c.carry {
+		c.address = int(v)<<8 | c.address&0xFF
+	}
+
+	return v
+}
+
+func (c *Cpu) Sha() byte {
+	return c.unstableStore(c.A & c.X)
+}
+
+func (c *Cpu) Shx() byte {
+	return c.unstableStore(c.X)
+}
+
+func (c *Cpu) Shy() byte {
+	return c.unstableStore(c.Y)
+}
+
+func (c *Cpu) Tas() byte {
+	c.StackPointer = c.A & c.X
+	return c.unstableStore(c.StackPointer)
+}
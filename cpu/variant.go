@@ -0,0 +1,126 @@
+package cpu
+
+// Variant is the member of the 6502 family a Cpu behaves as
+type Variant int
+
+const (
+	// The NES's CPU, an NMOS 6502 with decimal mode cut out. The D
+	// flag can still be set, ADC and SBC ignore it.
+	Ricoh2A03 Variant = iota
+
+	// The original NMOS 6502. Decimal mode ADC and SBC set N, V and Z
+	// from the binary result rather than the decimal one.
+	Nmos6502
+
+	// The CMOS 65C02, without the Rockwell and WDC bit instructions.
+	// It adds instructions and the (zp) addressing mode, turns every
+	// other opcode into a NOP, fixes JMP's page wrap bug and gets the
+	// flags right in decimal mode, at the cost of a cycle. Dummy
+	// cycles access the bus the way the NMOS parts do, except that
+	// read-modify-writes read twice rather than write twice.
+	Cmos65C02
+)
+
+func (v Variant) opcodes() *[0x100]opcode {
+	if v == Cmos65C02 {
+		return &cmosOpcodes
+	}
+
+	return &opcodes
+}
+
+// Lookup returns the instruction opcode runs as on v
+func (v Variant) Lookup(opcode byte) Instruction {
+	return v.opcodes()[opcode].Instruction
+}
+
+func (c *Cpu) decimal() bool {
+	return c.Variant != Ricoh2A03 && c.getDecimalMode()
+}
+
+// Decimal mode ADC and SBC as worked out by Bruce Clark
+//
+// http://www.6502.org/tutorials/decimal_mode.html
+func (c *Cpu) adcDecimal(val byte) {
+	a, b := int(c.A), int(val)
+	carry := int(c.P & 0x01)
+
+	low := a&0x0F + b&0x0F + carry
+	if low >= 0x0A {
+		low = (low+0x06)&0x0F + 0x10
+	}
+
+	sum := a&0xF0 + b&0xF0 + low
+	signed := int(int8(a&0xF0)) + int(int8(b&0xF0)) + low
+
+	// N and V come from the sum before the high digit is adjusted,
+	// and Z from the binary sum
+	c.testAndSetNegative(byte(sum))
+	c.testAndSetZero(byte(a + b + carry))
+
+	if signed < -128 || signed > 127 {
+		c.setOverflow()
+	} else {
+		c.clearOverflow()
+	}
+
+	if sum >= 0xA0 {
+		sum += 0x60
+	}
+
+	c.A = byte(sum)
+	c.testAndSetCarryAddition(sum)
+
+	if c.Variant == Cmos65C02 {
+		c.testAndSetNegative(c.A)
+		c.testAndSetZero(c.A)
+		c.fixDecimal = true
+	}
+}
+
+// The decimal result of SBC, the flags are worked out by Sbc
+func (c *Cpu) decimalSubtract(a, b byte) byte {
+	borrow := 1 - int(c.P&0x01)
+
+	low := int(a&0x0F) - int(b&0x0F) - borrow
+
+	if c.Variant == Cmos65C02 {
+		result := int(a) - int(b) - borrow
+		if result < 0 {
+			result -= 0x60
+		}
+
+		if low < 0 {
+			result -= 0x06
+		}
+
+		return byte(result)
+	}
+
+	if low < 0 {
+		low = (low-0x06)&0x0F - 0x10
+	}
+
+	result := int(a&0xF0) - int(b&0xF0) + low
+	if result < 0 {
+		result -= 0x60
+	}
+
+	return byte(result)
+}
+
+// The 65C02 spends the extra cycle of a decimal ADC or SBC reading
+// the byte after the instruction
+var decimalFixup = opcode{cycles: []microOp{fixDecimalResult}}
+
+func fixDecimalResult(c *Cpu) bool {
+	c.read(c.ProgramCounter)
+
+	return true
+}
+
+func (c *Cpu) clearCmosDecimal() {
+	if c.Variant == Cmos65C02 {
+		c.clearDecimalMode()
+	}
+}
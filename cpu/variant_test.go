@@ -0,0 +1,229 @@
+package cpu
+
+import (
+	"testing"
+)
+
+type decimalVector struct {
+	variant Variant
+	program []byte
+	a       byte
+	carry   bool
+	cycles  int
+
+	// A and P afterwards, P leaving out B, I and the unused bit
+	result byte
+	flags  byte
+}
+
+const (
+	flagC = 0x01
+	flagZ = 0x02
+	flagD = 0x08
+	flagV = 0x40
+	flagN = 0x80
+)
+
+func TestDecimalMode(test *testing.T) {
+	adc := []byte{0x69, 0x01}
+	sbc := []byte{0xE9, 0x01}
+
+	vectors := []decimalVector{
+		// The 2A03 ignores D
+		{Ricoh2A03, adc, 0x99, false, 2, 0x9A, flagN | flagD},
+		{Ricoh2A03, sbc, 0x00, true, 2, 0xFF, flagN | flagD},
+
+		// The NMOS 6502 takes Z from the binary sum and N from the
+		// sum before the high digit is adjusted
+		{Nmos6502, adc, 0x99, false, 2, 0x00, flagN | flagD | flagC},
+		{Nmos6502, []byte{0x69, 0x34}, 0x12, true, 2, 0x47, flagD},
+		{Nmos6502, []byte{0x69, 0x50}, 0x50, false, 2, 0x00, flagN | flagV | flagD | flagC},
+		{Nmos6502, sbc, 0x00, true, 2, 0x99, flagN | flagD},
+		{Nmos6502, []byte{0xE9, 0x25}, 0x50, true, 2, 0x25, flagD | flagC},
+		{Nmos6502, []byte{0xE9, 0x01}, 0x01, false, 2, 0x99, flagN | flagD},
+
+		// The 65C02 gets N and Z right and takes a cycle to do it
+		{Cmos65C02, adc, 0x99, false, 3, 0x00, flagZ | flagD | flagC},
+		{Cmos65C02, []byte{0x69, 0x34}, 0x12, true, 3, 0x47, flagD},
+		{Cmos65C02, []byte{0x69, 0x50}, 0x50, false, 3, 0x00, flagZ | flagV | flagD | flagC},
+		{Cmos65C02, sbc, 0x00, true, 3, 0x99, flagN | flagD},
+		{Cmos65C02, []byte{0xE9, 0x25}, 0x50, true, 3, 0x25, flagD | flagC},
+		{Cmos65C02, []byte{0xE9, 0x01}, 0x01, false, 3, 0x99, flagN | flagD},
+	}
+
+	for _, v := range vectors {
+		c, _ := newVariantTestCpu(v.variant, v.program...)
+		c.A = v.a
+		c.P = 0x24 | flagD
+
+		if v.carry {
+			c.P |= flagC
+		}
+
+		cycles, _ := c.Step()
+		flags := c.P &^ 0x34
+
+		if c.A != v.result || flags != v.flags || cycles != v.cycles {
+			test.Errorf("%d: % X with A=%02X C=%t gave A=%02X P=%02X in %d cycles, expected A=%02X P=%02X in %d",
+				v.variant, v.program, v.a, v.carry, c.A, flags, cycles, v.result, v.flags, v.cycles)
+		}
+	}
+}
+
+func TestCmosDecimalFixupReadsNextByte(test *testing.T) {
+	// ADC #$01
+	c, bus := newVariantTestCpu(Cmos65C02, 0x69, 0x01)
+	c.P |= flagD
+
+	c.Step()
+
+	verifyAccesses(bus, []string{
+		"read 8000",
+		"read 8001",
+		"read 8002",
+	}, test)
+}
+
+func TestInterruptsClearDecimalOnCmos(test *testing.T) {
+	for _, v := range []Variant{Nmos6502, Cmos65C02} {
+		// BRK
+		c, _ := newVariantTestCpu(v, 0x00)
+		c.P |= flagD
+
+		c.Step()
+
+		if cleared := c.P&flagD == 0; cleared != (v == Cmos65C02) {
+			test.Errorf("%d: BRK left P=%02X", v, c.P)
+		}
+	}
+}
+
+func TestCmosUndefinedOpcodes(test *testing.T) {
+	// KIL on the NMOS parts
+	c, _ := newVariantTestCpu(Ricoh2A03, 0x02)
+
+	if _, err := c.Step(); err == nil {
+		test.Errorf("02 didn't jam the 2A03")
+	}
+
+	vectors := []struct {
+		op     byte
+		size   int
+		cycles int
+	}{
+		{0x02, 2, 2},
+		{0x03, 1, 1},
+		{0x44, 2, 3},
+		{0x54, 2, 4},
+		{0x5C, 3, 8},
+		{0xDC, 3, 4},
+		{0xFB, 1, 1},
+	}
+
+	for _, v := range vectors {
+		c, _ := newVariantTestCpu(Cmos65C02, v.op)
+
+		cycles, err := c.Step()
+		size := c.ProgramCounter - 0x8000
+
+		if err != nil || size != v.size || cycles != v.cycles {
+			test.Errorf("%02X ran as %d bytes in %d cycles, expected %d bytes in %d", v.op, size, cycles, v.size, v.cycles)
+		}
+	}
+}
+
+func TestCmosInstructions(test *testing.T) {
+	c, bus := newVariantTestCpu(Cmos65C02,
+		0x64, 0x10, // STZ $10
+		0x04, 0x11, // TSB $11
+		0x1C, 0x00, 0x02, // TRB $0200
+		0xB2, 0x12, // LDA ($12)
+		0x1A,       // INC A
+		0x5A,       // PHY
+		0xFA,       // PLX
+		0x89, 0x00, // BIT #$00
+		0x80, 0x01, // BRA +1
+		0xEA,
+		0x7C, 0x00, 0x03, // JMP ($0300,X)
+	)
+
+	bus.memory[0x10] = 0xFF
+	bus.memory[0x11] = 0x0F
+	bus.memory[0x12] = 0x00
+	bus.memory[0x13] = 0x04
+	bus.memory[0x0200] = 0xFF
+	bus.memory[0x0400] = 0x7F
+	bus.memory[0x0380] = 0x34
+	bus.memory[0x0381] = 0x12
+
+	c.A = 0xF0
+	c.X = 0x02
+	c.Y = 0x80
+
+	for i := 0; i < 10; i++ {
+		if _, err := c.Step(); err != nil {
+			test.Fatalf("Step %d: %s", i, err.Error())
+		}
+	}
+
+	switch {
+	case bus.memory[0x10] != 0x00:
+		test.Errorf("STZ left %02X", bus.memory[0x10])
+	case bus.memory[0x11] != 0xFF:
+		test.Errorf("TSB left %02X", bus.memory[0x11])
+	case bus.memory[0x0200] != 0x0F:
+		test.Errorf("TRB left %02X", bus.memory[0x0200])
+	case c.A != 0x80:
+		test.Errorf("LDA ($12) and INC A left A=%02X", c.A)
+	case c.X != 0x80:
+		test.Errorf("PHY and PLX left X=%02X", c.X)
+	case c.P&flagZ == 0 || c.P&flagN == 0:
+		test.Errorf("BIT #$00 left P=%02X, expected Z set and N untouched", c.P)
+	case c.ProgramCounter != 0x1234:
+		test.Errorf("BRA and JMP ($0300,X) ended up at %04X", c.ProgramCounter)
+	}
+}
+
+func TestCmosJumpIndirectDoesntWrap(test *testing.T) {
+	// JMP ($02FF)
+	c, bus := newVariantTestCpu(Cmos65C02, 0x6C, 0xFF, 0x02)
+	bus.memory[0x02FF] = 0x34
+	bus.memory[0x0300] = 0x12
+
+	if cycles, _ := c.Step(); cycles != 6 || c.ProgramCounter != 0x1234 {
+		test.Errorf("JMP took %d cycles to %04X, expected 6 to 1234", cycles, c.ProgramCounter)
+	}
+}
+
+func TestCmosReadModifyWriteReadsTwice(test *testing.T) {
+	// ASL $12F0,X twice, crossing a page the second time
+	c, bus := newVariantTestCpu(Cmos65C02, 0x1E, 0xF0, 0x12, 0x1E, 0xF0, 0x12)
+	bus.memory[0x12F0] = 0x01
+	bus.memory[0x1310] = 0x01
+
+	if cycles, _ := c.Step(); cycles != 6 {
+		test.Errorf("ASL took %d cycles without crossing a page, expected 6", cycles)
+	}
+
+	c.X = 0x20
+
+	if cycles, _ := c.Step(); cycles != 7 {
+		test.Errorf("ASL took %d cycles crossing a page, expected 7", cycles)
+	}
+
+	verifyAccesses(bus, []string{
+		"read 8000",
+		"read 8001",
+		"read 8002",
+		"read 12F0",
+		"read 12F0",
+		"write 12F0 02",
+		"read 8003",
+		"read 8004",
+		"read 8005",
+		"read 1210",
+		"read 1310",
+		"read 1310",
+		"write 1310 02",
+	}, test)
+}
@@ -0,0 +1,72 @@
+package frontend
+
+import (
+	"fmt"
+	"github.com/jteeuwen/glfw"
+	"github.com/scottferg/Fergulator/input"
+)
+
+const (
+	KeyEventReset = 82
+	KeyEventSave  = 83
+	KeyEventLoad  = 76
+	KeyEventTrace = 84
+)
+
+// Keyboard layout for the first joypad
+var buttons = map[int]int{
+	90:             input.A, // Z
+	88:             input.B, // X
+	glfw.KeyRshift: input.Select,
+	glfw.KeyEnter:  input.Start,
+	glfw.KeyUp:     input.Up,
+	glfw.KeyDown:   input.Down,
+	glfw.KeyLeft:   input.Left,
+	glfw.KeyRight:  input.Right,
+}
+
+// KeyListener runs on the render thread, so everything that touches the
+// console is handed to its Run loop
+func (v *Video) KeyListener(key, state int) {
+	console := v.nes
+
+	if state == glfw.KeyPress {
+		switch key {
+		case glfw.KeyEsc:
+			v.running = false
+		case KeyEventReset:
+			console.Do(console.Reset)
+		case KeyEventLoad:
+			console.Do(func() {
+				if err := console.LoadState(v.saveStateFile); err != nil {
+					fmt.Println(err.Error())
+				} else {
+					fmt.Println("Loaded state")
+				}
+			})
+		case KeyEventSave:
+			console.Do(func() {
+				if err := console.SaveState(v.saveStateFile); err != nil {
+					fmt.Println(err.Error())
+				} else {
+					fmt.Println("Saved state")
+				}
+			})
+		case KeyEventTrace:
+			// Only does anything when started with -trace
+			console.Do(func() {
+				if console.ToggleTrace() {
+					fmt.Println("Tracing")
+				} else {
+					fmt.Println("Not tracing")
+				}
+			})
+		default:
+			if b, ok := buttons[key]; ok {
+				console.Do(func() { console.Controller.ButtonDown(b) })
+			}
+		}
+	} else if b, ok := buttons[key]; ok {
+		console.Do(func() { console.Controller.ButtonUp(b) })
+	}
+}
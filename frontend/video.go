@@ -1,25 +1,39 @@
-package main
+// Package frontend displays a console in an OpenGL window and feeds it
+// keyboard input
+package frontend
 
 import (
+	"context"
 	"fmt"
 	"github.com/0xe2-0x9a-0x9b/Go-SDL/gfx"
 	"github.com/banthar/gl"
 	"github.com/jteeuwen/glfw"
+	"github.com/scottferg/Fergulator/nes"
 	"math"
 	"os"
 	"runtime"
 )
 
 type Video struct {
-	tick       <-chan []uint32
-	debug      <-chan []uint32
+	nes           *nes.Console
+	running       bool
+	saveStateFile string
+
+	name   string
+	errors chan error
+
+	frames     chan *nes.Frame
 	fpsmanager *gfx.FPSmanager
 	tex        gl.Texture
 }
 
-func (v *Video) Init(t <-chan []uint32, d <-chan []uint32, n string) {
-	v.tick = t
-	v.debug = d
+func (v *Video) Init(console *nes.Console, n string) {
+	v.nes = console
+	v.running = true
+	v.saveStateFile = fmt.Sprintf(".%s.state", n)
+	v.name = n
+	v.errors = make(chan error, 1)
+	v.frames = make(chan *nes.Frame, 1)
 
 	if err := glfw.Init(); err != nil {
 		fmt.Fprintf(os.Stderr, "[e] %v\n", err)
@@ -39,8 +53,8 @@ func (v *Video) Init(t <-chan []uint32, d <-chan []uint32, n string) {
 
 	glfw.SetWindowTitle(fmt.Sprintf("Fergulator - %s", n))
 	glfw.SetWindowSizeCallback(reshape)
-	glfw.SetWindowCloseCallback(quit_event)
-	glfw.SetKeyCallback(KeyListener)
+	glfw.SetWindowCloseCallback(v.quit_event)
+	glfw.SetKeyCallback(v.KeyListener)
 	reshape(512, 480)
 
 	v.tex = gl.GenTexture()
@@ -74,23 +88,58 @@ func reshape(width int, height int) {
 	gl.Disable(gl.DEPTH_TEST)
 }
 
-func quit_event() int {
-	running = false
+func (v *Video) quit_event() int {
+	v.running = false
 	return 0
 }
 
-func (v *Video) Render() {
+// ShowError reports an emulation error, such as a jammed CPU, in the
+// window title, or clears it when err is nil. It's safe to call from
+// the emulation goroutine.
+func (v *Video) ShowError(err error) {
+	select {
+	case v.errors <- err:
+	default:
+	}
+}
+
+// ShowFrame queues a frame to be drawn, replacing any frame that
+// hasn't been drawn yet. It never blocks, so it's safe to call from
+// the emulation goroutine.
+func (v *Video) ShowFrame(f *nes.Frame) {
+	for {
+		select {
+		case v.frames <- f:
+			return
+		case old := <-v.frames:
+			old.Release()
+		}
+	}
+}
+
+// Render draws frames until the window is closed or ctx is done
+func (v *Video) Render(ctx context.Context) {
 	runtime.LockOSThread()
 
-	for running {
+	for v.running {
 		select {
-		case val := <-v.tick:
+		case <-ctx.Done():
+			v.running = false
+		case err := <-v.errors:
+			if err != nil {
+				glfw.SetWindowTitle(fmt.Sprintf("Fergulator - %s [%s]", v.name, err.Error()))
+			} else {
+				glfw.SetWindowTitle(fmt.Sprintf("Fergulator - %s", v.name))
+			}
+		case f := <-v.frames:
+			val := f.Pixels
 			slice := make([]uint8, len(val)*3)
 			for i := 0; i < len(val); i = i + 1 {
 				slice[i*3+0] = (uint8)((val[i] >> 16) & 0xff)
 				slice[i*3+1] = (uint8)((val[i] >> 8) & 0xff)
 				slice[i*3+2] = (uint8)((val[i]) & 0xff)
 			}
+			f.Release()
 
 			gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
@@ -0,0 +1,83 @@
+// Package input implements the standard NES joypad. It knows nothing
+// about keyboards or windows; frontends translate their own key codes
+// into the buttons below.
+package input
+
+// Joypad buttons, in the order the shift register reports them
+const (
+	A = iota
+	B
+	Select
+	Start
+	Up
+	Down
+	Left
+	Right
+)
+
+type Controller struct {
+	ButtonState [8]byte
+	StrobeState int
+	LastWrite   byte
+}
+
+func (c *Controller) SetButtonState(b int, v byte) {
+	switch b {
+	case A: // A
+		c.ButtonState[0] = v
+	case B: // B
+		c.ButtonState[1] = v
+	case Select: // Select
+		c.ButtonState[2] = v
+	case Start: // Start
+		c.ButtonState[3] = v
+	case Up: // Up
+		c.ButtonState[4] = v
+	case Down: // Down
+		c.ButtonState[5] = v
+	case Left: // Left
+		c.ButtonState[6] = v
+	case Right: // Right
+		c.ButtonState[7] = v
+	}
+}
+
+func (c *Controller) ButtonDown(b int) {
+	c.SetButtonState(b, 0x41)
+}
+
+func (c *Controller) ButtonUp(b int) {
+	c.SetButtonState(b, 0x40)
+}
+
+func (c *Controller) Write(v byte) {
+	if v == 0 && c.LastWrite == 1 {
+		c.StrobeState = 0
+	}
+
+	c.LastWrite = v
+}
+
+func (c *Controller) Read() (r byte) {
+	if c.StrobeState < 8 {
+		r = c.ButtonState[c.StrobeState]
+	} else if c.StrobeState == 19 {
+		r = 0x1
+	} else {
+		r = 0x0
+	}
+
+	c.StrobeState++
+
+	if c.StrobeState == 24 {
+		c.StrobeState = 0x0
+	}
+
+	return r
+}
+
+func (c *Controller) Init() {
+	for i := 0; i < len(c.ButtonState); i++ {
+		c.ButtonState[i] = 0x40
+	}
+}
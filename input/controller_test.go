@@ -0,0 +1,38 @@
+package input
+
+import (
+	"testing"
+)
+
+func TestButtonsReadInOrder(test *testing.T) {
+	c := new(Controller)
+	c.Init()
+
+	c.ButtonDown(A)
+	c.ButtonDown(Start)
+	c.ButtonDown(Right)
+
+	// Strobe the shift register
+	c.Write(1)
+	c.Write(0)
+
+	expected := []byte{0x41, 0x40, 0x40, 0x41, 0x40, 0x40, 0x40, 0x41}
+	for i, e := range expected {
+		if r := c.Read(); r != e {
+			test.Errorf("Button %d read 0x%X, expected 0x%X\n", i, r, e)
+		}
+	}
+
+	c.ButtonUp(Start)
+
+	c.Write(1)
+	c.Write(0)
+
+	c.Read()
+	c.Read()
+	c.Read()
+
+	if r := c.Read(); r != 0x40 {
+		test.Errorf("Released Start read 0x%X, expected 0x40\n", r)
+	}
+}
@@ -1,9 +0,0 @@
-package main
-
-import (
-	"testing"
-)
-
-func TestMirroring(test *testing.T) {
-	Ram.Init()
-}
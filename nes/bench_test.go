@@ -0,0 +1,60 @@
+package nes
+
+import (
+	"testing"
+)
+
+// Instructions run per BenchmarkNesstress iteration, roughly ten
+// frames' worth
+const benchSteps = 100000
+
+func BenchmarkNesstress(b *testing.B) {
+	nes := loadTestConsole("../test_roms/nesstress.nes", b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for s := 0; s < benchSteps; s++ {
+			nes.Step()
+		}
+	}
+}
+
+func BenchmarkBusRead(b *testing.B) {
+	nes := loadTestConsole("../test_roms/nesstress.nes", b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		nes.Ram.Read(uint16(i & 0x7FF))
+		nes.Ram.Read(0x8000 + uint16(i&0x7FFF))
+	}
+}
+
+func BenchmarkBusWrite(b *testing.B) {
+	nes := NewConsole()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		nes.Ram.Write(uint16(i&0x7FF), byte(i))
+		nes.Ram.Write(0x6000+uint16(i&0x1FFF), byte(i))
+	}
+}
+
+// Running a frame once the console is warmed up shouldn't allocate
+func TestRunFrameAllocations(test *testing.T) {
+	nes := loadTestConsole("../test_roms/nesstress.nes", test)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		f, _ := nes.RunFrame()
+		f.Release()
+	})
+
+	if allocs != 0 {
+		test.Errorf("RunFrame made %.1f allocations, expected none", allocs)
+	}
+}
@@ -0,0 +1,93 @@
+// Package nes wires the CPU, PPU, cartridge and controllers together
+// into a complete console.
+package nes
+
+import (
+	"github.com/scottferg/Fergulator/cartridge"
+	"github.com/scottferg/Fergulator/cpu"
+	"github.com/scottferg/Fergulator/input"
+	"github.com/scottferg/Fergulator/ppu"
+	"hash/crc32"
+	"sync"
+	"time"
+)
+
+// Console is a single emulated NES. It owns every piece of machine
+// state, and each component reaches the others through it, so several
+// independent consoles can run side by side in one process.
+type Console struct {
+	Cpu        *cpu.Cpu
+	Ppu        *ppu.Ppu
+	Ram        *Memory
+	Rom        cartridge.Mapper
+	Controller *input.Controller
+
+	Region    Region
+	Scheduler Scheduler
+
+	// Where Run keeps battery RAM saved, and how often
+	BatteryFile      string
+	AutosaveInterval time.Duration
+
+	// Called on Run's goroutine with the error from an autosave that
+	// failed
+	AutosaveFailed func(err error)
+
+	romHash  uint32
+	tracer   *Tracer
+	frames   chan *Frame
+	samples  []float32
+	commands chan func()
+
+	// Closed when Run returns, nil until it's first called
+	stopped     chan struct{}
+	stoppedLock sync.Mutex
+}
+
+func NewConsole() *Console {
+	nes := &Console{
+		frames:   make(chan *Frame, frameBuffers),
+		commands: make(chan func(), commandBuffers),
+	}
+
+	nes.Ram = &Memory{nes: nes}
+	nes.Cpu = cpu.NewCpu(nes.Ram)
+	nes.Ppu = &ppu.Ppu{Cpu: nes.Cpu}
+	nes.Controller = &input.Controller{}
+
+	nes.Ram.Init()
+	nes.Ram.mapConsole()
+	nes.Ppu.Init()
+	nes.Controller.Init()
+	nes.SetRegion(Ntsc)
+
+	return nes
+}
+
+// LoadRom parses an iNES image, installs its mapper and points the
+// CPU at the reset vector
+func (nes *Console) LoadRom(contents []byte) (err error) {
+	if nes.Rom, err = cartridge.LoadRom(contents, nes.Cpu, nes.Ppu); err != nil {
+		return
+	}
+
+	nes.Ram.Map(0x8000, 0xFFFF, nes.Rom.Read, nes.Rom.Write)
+
+	nes.Ppu.Rom = nes.Rom
+	nes.romHash = crc32.ChecksumIEEE(contents)
+	nes.setResetVector()
+
+	return
+}
+
+func (nes *Console) setResetVector() {
+	high := nes.Ram.Read(0xFFFD)
+	low := nes.Ram.Read(0xFFFC)
+
+	nes.Cpu.ProgramCounter = (int(high) << 8) + int(low)
+}
+
+// Reset presses the console's reset button
+func (nes *Console) Reset() {
+	nes.Cpu.Reset()
+}
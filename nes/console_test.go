@@ -0,0 +1,102 @@
+package nes
+
+import (
+	"github.com/scottferg/Fergulator/cpu"
+	"io/ioutil"
+	"sync"
+	"testing"
+)
+
+func loadTestConsole(path string, test testing.TB) *Console {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	nes := NewConsole()
+	if err = nes.LoadRom(contents); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	return nes
+}
+
+func TestConsolesAreIndependent(test *testing.T) {
+	a := loadTestConsole("../test_roms/nestest.nes", test)
+	b := loadTestConsole("../test_roms/nestest.nes", test)
+
+	a.Ram.Write(0x0300, 0x42)
+
+	if v := b.Ram.Read(0x0300); v != 0x00 {
+		test.Errorf("Write to one console leaked into another: 0x%X", v)
+	}
+
+	if a.Cpu.Bus != a.Ram || b.Cpu.Bus != b.Ram || a.Ppu.Cpu != a.Cpu || b.Ppu.Cpu != b.Cpu {
+		test.Errorf("Components are not wired to their own console")
+	}
+}
+
+func TestConsolesRunSideBySide(test *testing.T) {
+	consoles := []*Console{
+		loadTestConsole("../test_roms/nestest.nes", test),
+		loadTestConsole("../test_roms/nestest.nes", test),
+	}
+
+	var wg sync.WaitGroup
+	for _, nes := range consoles {
+		wg.Add(1)
+
+		go func(nes *Console) {
+			defer wg.Done()
+
+			nes.Cpu.ProgramCounter = 0xC000
+			nes.Cpu.P = 0x24
+
+			for i := 0; i < 1000; i++ {
+				nes.Step()
+			}
+		}(nes)
+	}
+
+	wg.Wait()
+
+	a, b := consoles[0].Cpu, consoles[1].Cpu
+	if a.ProgramCounter != b.ProgramCounter || a.A != b.A || a.X != b.X ||
+		a.Y != b.Y || a.P != b.P || a.StackPointer != b.StackPointer {
+		test.Errorf("Consoles diverged: PC 0x%X/0x%X", a.ProgramCounter, b.ProgramCounter)
+	}
+}
+
+func TestInvalidOpcodeJamsCpu(test *testing.T) {
+	// One PRG bank whose reset vector points at an unofficial
+	// opcode, 0x02, which jams the CPU
+	image := make([]byte, 16+0x4000+0x2000)
+	copy(image, []byte{'N', 'E', 'S', 0x1a, 1, 1})
+	image[16] = 0x02
+	image[16+0x3FFC] = 0x00
+	image[16+0x3FFD] = 0x80
+
+	nes := NewConsole()
+	if err := nes.LoadRom(image); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	_, err := nes.Step()
+
+	e, ok := err.(*cpu.InvalidOpcodeError)
+	if !ok {
+		test.Fatalf("Step returned %v, expected InvalidOpcodeError", err)
+	}
+
+	if e.Opcode != 0x02 || e.ProgramCounter != 0x8000 || e.Bank != 0 {
+		test.Errorf("Error reported opcode 0x%X at 0x%X, bank %d", e.Opcode, e.ProgramCounter, e.Bank)
+	}
+
+	if !nes.Cpu.Jammed {
+		test.Errorf("CPU was not jammed")
+	}
+
+	if _, err = nes.Step(); err != e {
+		test.Errorf("Jammed CPU returned %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package nes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpriteRam(test *testing.T) {
+	// blargg's PPU tests print $01 when everything passes
+	if text := runTestRom("../test_roms/blargg_ppu/sprite_ram.nes", test); !strings.Contains(text, "$01") {
+		test.Errorf("sprite_ram failed:\n%s", text)
+	}
+}
+
+func TestOamDmaRunsThePpu(test *testing.T) {
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+
+	// LDA #$02, STA $4014
+	nes.Cpu.ProgramCounter = 0x0400
+	copy(nes.Ram.ram[0x400:], []byte{0xA9, 0x02, 0x8D, 0x14, 0x40})
+
+	nes.Step()
+	nes.Step()
+
+	dots := nes.Ppu.Scanline*341 + nes.Ppu.Cycle
+	cycles, _ := nes.Step()
+
+	if cycles != 513 && cycles != 514 {
+		test.Errorf("OAM DMA took %d cycles, expected 513 or 514", cycles)
+	}
+
+	if elapsed := nes.Ppu.Scanline*341 + nes.Ppu.Cycle - dots; elapsed != cycles*3 {
+		test.Errorf("PPU ran %d dots during OAM DMA, expected %d", elapsed, cycles*3)
+	}
+}
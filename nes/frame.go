@@ -0,0 +1,67 @@
+package nes
+
+import (
+	"sync/atomic"
+)
+
+// Frames the console cycles through: one being drawn by the PPU, one
+// being shown and one waiting to be
+const frameBuffers = 3
+
+// Frame is one finished picture and the audio that played while it
+// was drawn. Nothing writes to a Frame once RunFrame has returned it,
+// so it can be handed to another goroutine as is. Call Release once
+// it's no longer needed so the console can reuse its buffers.
+type Frame struct {
+	Number int
+
+	// 256x240 pixels, 0xRRGGBB
+	Pixels []uint32
+
+	// Samples pushed through AddSample during the frame. There's no
+	// APU yet so this stays empty.
+	Audio []float32
+
+	pool     chan *Frame
+	released int32
+}
+
+// Release hands the frame back to its console. The frame mustn't be
+// touched afterwards; releasing it twice does nothing.
+func (f *Frame) Release() {
+	if !atomic.CompareAndSwapInt32(&f.released, 0, 1) {
+		return
+	}
+
+	select {
+	case f.pool <- f:
+	default:
+	}
+}
+
+// AddSample queues an audio sample for the frame being run
+func (nes *Console) AddSample(s float32) {
+	nes.samples = append(nes.samples, s)
+}
+
+// Swaps the PPU's finished framebuffer out for a free one
+func (nes *Console) finishFrame() *Frame {
+	var f *Frame
+
+	select {
+	case f = <-nes.frames:
+		atomic.StoreInt32(&f.released, 0)
+	default:
+		f = &Frame{
+			Pixels: make([]uint32, len(nes.Ppu.Framebuffer)),
+			pool:   nes.frames,
+		}
+	}
+
+	f.Number = nes.Ppu.FrameCount
+	f.Pixels, nes.Ppu.Framebuffer = nes.Ppu.Framebuffer, f.Pixels
+	f.Audio = append(f.Audio[:0], nes.samples...)
+	nes.samples = nes.samples[:0]
+
+	return f
+}
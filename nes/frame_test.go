@@ -0,0 +1,76 @@
+package nes
+
+import (
+	"testing"
+)
+
+func TestRunFrameReturnsEachFrame(test *testing.T) {
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+
+	for i := 1; i <= 3; i++ {
+		f, err := nes.RunFrame()
+		if err != nil {
+			test.Fatal(err.Error())
+		}
+
+		if f.Number != i || len(f.Pixels) != 256*240 {
+			test.Errorf("Frame %d was numbered %d with %d pixels", i, f.Number, len(f.Pixels))
+		}
+
+		f.Release()
+	}
+}
+
+func TestHeldFramesAreNotOverwritten(test *testing.T) {
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+
+	held, _ := nes.RunFrame()
+	held.Pixels[0] = 0x123456
+
+	for i := 0; i < frameBuffers*2; i++ {
+		f, _ := nes.RunFrame()
+		if &f.Pixels[0] == &held.Pixels[0] {
+			test.Fatalf("Frame %d reused a buffer that was still held", f.Number)
+		}
+
+		f.Release()
+	}
+
+	if held.Pixels[0] != 0x123456 || held.Number != 1 {
+		test.Errorf("Held frame was overwritten")
+	}
+}
+
+func TestFramesAcrossGoroutines(test *testing.T) {
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+
+	frames := make(chan *Frame, 1)
+	done := make(chan bool)
+
+	go func() {
+		var sum uint32
+		for f := range frames {
+			for _, p := range f.Pixels {
+				sum += p
+			}
+			f.Release()
+			f.Release()
+		}
+
+		done <- true
+	}()
+
+	for i := 0; i < 10; i++ {
+		nes.AddSample(float32(i))
+
+		f, _ := nes.RunFrame()
+		if len(f.Audio) != 1 || f.Audio[0] != float32(i) {
+			test.Errorf("Frame %d carried audio %v", f.Number, f.Audio)
+		}
+
+		frames <- f
+	}
+
+	close(frames)
+	<-done
+}
@@ -0,0 +1,148 @@
+package nes
+
+// The CPU bus is decoded in 32 byte pages, the size of the smallest
+// region on it ($4000-$401F)
+const busPageSize = 0x20
+
+// ReadFunc answers a CPU read from an address inside a mapped region
+type ReadFunc func(a uint16) byte
+
+// WriteFunc receives a CPU write to an address inside a mapped region
+type WriteFunc func(a uint16, v byte)
+
+type busRegion struct {
+	read  ReadFunc
+	write WriteFunc
+}
+
+// Memory is the CPU address bus. Each device registers handlers for
+// the range it decodes; reads nobody answers return whatever was
+// last left on the data bus, and writes nobody answers are dropped.
+type Memory struct {
+	nes     *Console
+	regions [0x10000 / busPageSize]busRegion
+
+	// 2k of internal RAM, mirrored through $0000-$1FFF
+	ram [0x800]byte
+	// Cartridge work RAM at $6000-$7FFF, dirty once the game has
+	// changed it since it was last loaded or saved
+	prgRam      [0x2000]byte
+	prgRamDirty bool
+
+	openBus byte
+}
+
+func (m *Memory) Init() {
+	for index, _ := range m.ram {
+		m.ram[index] = 0x00
+	}
+
+	for index, _ := range m.prgRam {
+		m.prgRam[index] = 0x00
+	}
+
+	m.prgRamDirty = false
+	m.openBus = 0x00
+}
+
+// Map hands start-end to a device, replacing whatever was mapped
+// there before. Both ends are rounded out to 32 byte pages. A nil
+// read or write leaves that direction unmapped.
+func (m *Memory) Map(start, end uint16, read ReadFunc, write WriteFunc) {
+	for p := int(start) / busPageSize; p <= int(end)/busPageSize; p++ {
+		m.regions[p] = busRegion{read: read, write: write}
+	}
+}
+
+// Maps the devices that live on the console itself. PRG-ROM is
+// mapped once a cartridge is loaded.
+func (m *Memory) mapConsole() {
+	m.Map(0x0000, 0x1FFF, m.readRam, m.writeRam)
+	m.Map(0x2000, 0x3FFF, m.readPpu, m.writePpu)
+	m.Map(0x4000, 0x401F, m.readIo, m.writeIo)
+	// $4020-$5FFF is cartridge expansion space, nothing we
+	// support decodes it
+	m.Map(0x6000, 0x7FFF, m.readPrgRam, m.writePrgRam)
+}
+
+func (m *Memory) Write(a uint16, v byte) {
+	m.openBus = v
+
+	if w := m.regions[a/busPageSize].write; w != nil {
+		w(a, v)
+	}
+}
+
+func (m *Memory) Read(a uint16) byte {
+	if r := m.regions[a/busPageSize].read; r != nil {
+		m.openBus = r(a)
+	}
+
+	return m.openBus
+}
+
+// Peek reads a for debugging and tracing, without the side effects a
+// CPU read would have. Registers can't be read that way and answer
+// $FF.
+func (m *Memory) Peek(a uint16) byte {
+	if a >= 0x2000 && a < 0x6000 {
+		return 0xFF
+	}
+
+	if r := m.regions[a/busPageSize].read; r != nil {
+		return r(a)
+	}
+
+	return m.openBus
+}
+
+func (m *Memory) readRam(a uint16) byte {
+	return m.ram[a&0x7FF]
+}
+
+func (m *Memory) writeRam(a uint16, v byte) {
+	m.ram[a&0x7FF] = v
+}
+
+// PPU registers repeat every 8 bytes through $3FFF
+func (m *Memory) readPpu(a uint16) byte {
+	return m.nes.Ppu.PpuRegRead(0x2000 + int(a&0x7))
+}
+
+func (m *Memory) writePpu(a uint16, v byte) {
+	m.nes.Ppu.PpuRegWrite(v, 0x2000+int(a&0x7))
+}
+
+// The APU isn't emulated, so only the joypads answer reads here.
+// They only drive the low bits; the rest float.
+func (m *Memory) readIo(a uint16) byte {
+	switch a {
+	case 0x4016:
+		return m.openBus&0xE0 | m.nes.Controller.Read()&0x1F
+	case 0x4017:
+		// No second controller
+		return m.openBus & 0xE0
+	}
+
+	return m.openBus
+}
+
+func (m *Memory) writeIo(a uint16, v byte) {
+	switch a {
+	case 0x4014:
+		m.nes.Cpu.StartOamDma(v)
+	case 0x4016:
+		m.nes.Controller.Write(v)
+	}
+}
+
+func (m *Memory) readPrgRam(a uint16) byte {
+	return m.prgRam[a&0x1FFF]
+}
+
+func (m *Memory) writePrgRam(a uint16, v byte) {
+	if m.prgRam[a&0x1FFF] != v {
+		m.prgRam[a&0x1FFF] = v
+		m.prgRamDirty = true
+	}
+}
@@ -0,0 +1,83 @@
+package nes
+
+import (
+	"testing"
+)
+
+func TestMirroring(test *testing.T) {
+	nes := NewConsole()
+	nes.Ram.Init()
+
+	nes.Ram.Write(0x0123, 0x42)
+
+	for _, a := range []uint16{0x0923, 0x1123, 0x1923} {
+		if v := nes.Ram.Read(a); v != 0x42 {
+			test.Errorf("0x%X was 0x%X, expected 0x42", a, v)
+		}
+	}
+
+	nes.Ram.Write(0x1FFF, 0x24)
+
+	if v := nes.Ram.Read(0x07FF); v != 0x24 {
+		test.Errorf("0x07FF was 0x%X, expected 0x24", v)
+	}
+}
+
+func TestPpuRegisterMirroring(test *testing.T) {
+	nes := NewConsole()
+
+	// $3FFE is PPUADDR, $2007 PPUDATA
+	nes.Ram.Write(0x3FFE, 0x3F)
+	nes.Ram.Write(0x2006, 0x01)
+	nes.Ram.Write(0x2007, 0x15)
+
+	if v := nes.Ppu.PaletteRam[1]; v != 0x15 {
+		test.Errorf("Palette entry 1 was 0x%X, expected 0x15", v)
+	}
+}
+
+func TestOpenBus(test *testing.T) {
+	nes := NewConsole()
+
+	nes.Ram.Write(0x0000, 0x5A)
+	nes.Ram.Read(0x0000)
+
+	// Nothing decodes expansion space or unloaded PRG-ROM
+	for _, a := range []uint16{0x4020, 0x5000, 0x8000, 0xFFFF} {
+		if v := nes.Ram.Read(a); v != 0x5A {
+			test.Errorf("0x%X was 0x%X, expected open bus 0x5A", a, v)
+		}
+	}
+
+	nes.Ram.Write(0x5000, 0x11)
+
+	if v := nes.Ram.Read(0x0000); v != 0x5A {
+		test.Errorf("Write to expansion space reached RAM")
+	}
+}
+
+func TestPrgRam(test *testing.T) {
+	nes := NewConsole()
+
+	nes.Ram.Write(0x6000, 0x12)
+	nes.Ram.Write(0x7FFF, 0x34)
+
+	if v := nes.Ram.Read(0x6000); v != 0x12 {
+		test.Errorf("0x6000 was 0x%X, expected 0x12", v)
+	}
+
+	if v := nes.Ram.Read(0x7FFF); v != 0x34 {
+		test.Errorf("0x7FFF was 0x%X, expected 0x34", v)
+	}
+}
+
+func TestPrgRomIsReadOnly(test *testing.T) {
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+
+	v := nes.Ram.Read(0xC000)
+	nes.Ram.Write(0xC000, ^v)
+
+	if r := nes.Ram.Read(0xC000); r != v {
+		test.Errorf("PRG-ROM at 0xC000 changed from 0x%X to 0x%X", v, r)
+	}
+}
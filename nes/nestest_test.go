@@ -0,0 +1,133 @@
+package nes
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// Log lines shown either side of the first divergence
+const goldLogContext = 5
+
+// PPU dots in an NTSC frame, used to turn the log's scanline and dot
+// columns back into elapsed CPU cycles
+const frameDots = 262 * 341
+
+type CpuState struct {
+	A  int
+	X  int
+	Y  int
+	P  int
+	S  int
+	C  int
+	Op int
+
+	Scanline int
+	Dot      int
+}
+
+func (s CpuState) String() string {
+	return fmt.Sprintf("%04X A:%02X X:%02X Y:%02X P:%02X SP:%02X CYC:%3d SL:%d (CPU cycle %d)",
+		s.Op, s.A, s.X, s.Y, s.P, s.S, s.Dot, s.Scanline, s.C)
+}
+
+// Reads the program counter, registers and PPU position from a line
+// of nestest.log. The log has no CPU cycle column, C is left for the
+// caller to work out.
+func parseLogLine(line string) (s CpuState, err error) {
+	if _, err = fmt.Sscanf(line[:4], "%X", &s.Op); err != nil {
+		return
+	}
+
+	_, err = fmt.Sscanf(line[48:], "A:%X X:%X Y:%X P:%X SP:%X CYC:%d SL:%d",
+		&s.A, &s.X, &s.Y, &s.P, &s.S, &s.Dot, &s.Scanline)
+	return
+}
+
+func consoleState(nes *Console, cycles int) CpuState {
+	c := nes.Cpu
+
+	return CpuState{
+		A:        int(c.A),
+		X:        int(c.X),
+		Y:        int(c.Y),
+		P:        int(c.P),
+		S:        int(c.StackPointer),
+		C:        cycles,
+		Op:       c.ProgramCounter,
+		Scanline: nes.Ppu.Scanline,
+		Dot:      nes.Ppu.Cycle - 1,
+	}
+}
+
+func logContext(log []string, line int) string {
+	var context []string
+
+	for i := line - goldLogContext; i <= line+goldLogContext; i++ {
+		if i < 0 || i >= len(log) || log[i] == "" {
+			continue
+		}
+
+		marker := "  "
+		if i == line {
+			marker = "> "
+		}
+
+		context = append(context, fmt.Sprintf("%s%5d  %s", marker, i+1, strings.TrimRight(log[i], "\r")))
+	}
+
+	return strings.Join(context, "\n")
+}
+
+// Sets nestest up to run its automated mode from where nestest.log
+// starts, and returns the log's lines
+func loadGoldLog(test *testing.T) (*Console, []string) {
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+
+	c := nes.Cpu
+	c.ProgramCounter = 0xC000
+	c.P = 0x24
+	c.Accurate = false
+
+	// The log starts at the top of vblank
+	nes.Ppu.Scanline = 241
+	nes.Ppu.Cycle = 1
+
+	logfile, err := ioutil.ReadFile("../test_roms/nestest.log")
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	return nes, strings.Split(string(logfile), "\n")
+}
+
+func TestGoldLog(test *testing.T) {
+	nes, log := loadGoldLog(test)
+
+	var err error
+
+	var expected, previous CpuState
+	cycles := 0
+
+	// Runs through the unofficial opcodes from line 5004 on
+	for i := 0; i < len(log) && log[i] != ""; i++ {
+		if expected, err = parseLogLine(log[i]); err != nil {
+			test.Fatalf("Bad line %d in nestest.log: %s", i+1, err.Error())
+		}
+
+		if i > 0 {
+			dots := (expected.Scanline-previous.Scanline)*341 + expected.Dot - previous.Dot
+			expected.C = previous.C + (dots+frameDots)%frameDots/3
+		}
+
+		if actual := consoleState(nes, cycles); actual != expected {
+			test.Fatalf("Diverged from nestest.log at line %d\n%s\n\nexpected %s\n     got %s",
+				i+1, logContext(log, i), expected, actual)
+		}
+
+		n, _ := nes.Step()
+		cycles += n
+		previous = expected
+	}
+}
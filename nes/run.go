@@ -0,0 +1,115 @@
+package nes
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// How often Run writes battery RAM back to BatteryFile while the game
+// keeps changing it
+const DefaultAutosaveInterval = 30 * time.Second
+
+// How many calls to Do can wait for Run before Do blocks
+const commandBuffers = 16
+
+// ErrStopped is returned by Do once Run has returned
+var ErrStopped = errors.New("The console has stopped running")
+
+// Run emulates the console in real time until ctx is cancelled,
+// handing each frame and any error from running it to show. show is
+// called on Run's goroutine and owns the frame it's given. Functions
+// passed to Do are run between frames.
+//
+// If BatteryFile is set, battery RAM is saved there every
+// AutosaveInterval while the game has written to it, and once more
+// on the way out, even if show panics. A failed autosave is passed to
+// AutosaveFailed and retried at the next interval; if the final save
+// fails its error is returned in place of ctx's.
+func (nes *Console) Run(ctx context.Context, show func(f *Frame, err error)) (err error) {
+	stopped := make(chan struct{})
+	nes.setStopped(stopped)
+	defer close(stopped)
+
+	defer func() {
+		if e := nes.flushBatteryRam(); e != nil {
+			err = e
+		}
+	}()
+
+	interval := nes.AutosaveInterval
+	if interval == 0 {
+		interval = DefaultAutosaveInterval
+	}
+
+	frames := time.NewTicker(time.Duration(float64(time.Second) / nes.Region.FrameRate()))
+	defer frames.Stop()
+
+	autosave := time.NewTicker(interval)
+	defer autosave.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case f := <-nes.commands:
+			f()
+		case <-autosave.C:
+			if err := nes.flushBatteryRam(); err != nil && nes.AutosaveFailed != nil {
+				nes.AutosaveFailed(err)
+			}
+		case <-frames.C:
+			// select picks at random when the context is done too,
+			// but no frame runs once it's been cancelled
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			show(nes.RunFrame())
+		}
+	}
+}
+
+// Do runs f on Run's goroutine between frames, where the CPU is between
+// instructions. Anything that changes a running console from another
+// goroutine, such as a frontend's reset button, save states or joypad,
+// goes through here. It blocks while too many calls are waiting.
+//
+// Calls made before Run is started wait for it. Once Run has returned
+// Do returns ErrStopped, and anything still waiting runs when Run is
+// next called.
+func (nes *Console) Do(f func()) error {
+	nes.stoppedLock.Lock()
+	stopped := nes.stopped
+	nes.stoppedLock.Unlock()
+
+	// select picks at random when both are ready
+	select {
+	case <-stopped:
+		return ErrStopped
+	default:
+	}
+
+	select {
+	case nes.commands <- f:
+		return nil
+	case <-stopped:
+		return ErrStopped
+	}
+}
+
+func (nes *Console) setStopped(stopped chan struct{}) {
+	nes.stoppedLock.Lock()
+	nes.stopped = stopped
+	nes.stoppedLock.Unlock()
+}
+
+// Saves battery RAM if the game has changed it since it was last
+// loaded or saved
+func (nes *Console) flushBatteryRam() error {
+	if nes.BatteryFile == "" || !nes.Ram.prgRamDirty {
+		return nil
+	}
+
+	return nes.SaveBatteryRam(nes.BatteryFile)
+}
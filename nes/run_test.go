@@ -0,0 +1,191 @@
+package nes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func batteryTestConsole(test *testing.T) (*Console, string) {
+	dir, err := ioutil.TempDir("", "fergulator")
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+	nes.BatteryFile = filepath.Join(dir, "nestest.battery")
+
+	return nes, dir
+}
+
+func TestRunStopsWhenCancelled(test *testing.T) {
+	nes, dir := batteryTestConsole(test)
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	frames := 0
+	err := nes.Run(ctx, func(f *Frame, err error) {
+		frames++
+		f.Release()
+
+		if frames == 3 {
+			cancel()
+		}
+	})
+
+	if err != context.Canceled || frames != 3 {
+		test.Errorf("Run returned %v after %d frames", err, frames)
+	}
+
+	// Nothing was written to PRG-RAM so there's nothing to save
+	if _, err := os.Stat(nes.BatteryFile); !os.IsNotExist(err) {
+		test.Errorf("Clean battery RAM was saved")
+	}
+}
+
+func TestRunFlushesBatteryRam(test *testing.T) {
+	nes, dir := batteryTestConsole(test)
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	nes.Run(ctx, func(f *Frame, err error) {
+		nes.Ram.Write(0x6000, 0x42)
+		cancel()
+	})
+
+	saved, err := ioutil.ReadFile(nes.BatteryFile)
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+
+	if saved[0] != 0x42 {
+		test.Errorf("Battery RAM saved 0x%X, expected 0x42", saved[0])
+	}
+}
+
+func TestRunFlushesBatteryRamOnPanic(test *testing.T) {
+	nes, dir := batteryTestConsole(test)
+	defer os.RemoveAll(dir)
+
+	func() {
+		defer func() {
+			recover()
+		}()
+
+		nes.Run(context.Background(), func(f *Frame, err error) {
+			nes.Ram.Write(0x7FFF, 0x24)
+			panic("frontend crashed")
+		})
+	}()
+
+	if saved, err := ioutil.ReadFile(nes.BatteryFile); err != nil || saved[0x1FFF] != 0x24 {
+		test.Errorf("Battery RAM wasn't saved when Run panicked")
+	}
+}
+
+func TestRunAutosavesBatteryRam(test *testing.T) {
+	nes, dir := batteryTestConsole(test)
+	defer os.RemoveAll(dir)
+
+	nes.AutosaveInterval = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	saved := false
+	nes.Run(ctx, func(f *Frame, err error) {
+		if f.Number == 1 {
+			nes.Ram.Write(0x6000, 0x42)
+		}
+
+		if _, err := os.Stat(nes.BatteryFile); err == nil {
+			saved = true
+			cancel()
+		}
+
+		// Give up well after the autosave was due
+		if f.Number > 30 {
+			cancel()
+		}
+	})
+
+	if !saved {
+		test.Errorf("Battery RAM wasn't autosaved while running")
+	}
+}
+
+func TestRunReportsFailedAutosave(test *testing.T) {
+	nes, dir := batteryTestConsole(test)
+	defer os.RemoveAll(dir)
+
+	nes.BatteryFile = filepath.Join(dir, "missing", "nestest.battery")
+	nes.AutosaveInterval = 20 * time.Millisecond
+
+	var failed error
+	nes.AutosaveFailed = func(err error) {
+		failed = err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := nes.Run(ctx, func(f *Frame, err error) {
+		nes.Ram.Write(0x6000, 0x42)
+
+		if failed != nil || f.Number > 30 {
+			cancel()
+		}
+	})
+
+	if failed == nil {
+		test.Errorf("A failed autosave wasn't reported")
+	}
+
+	if err == nil || err == context.Canceled {
+		test.Errorf("Run returned %v, expected the final save's error", err)
+	}
+}
+
+func TestRunDoesCommandsBetweenFrames(test *testing.T) {
+	nes, dir := batteryTestConsole(test)
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	executing := true
+	nes.Do(func() {
+		executing = nes.Cpu.Executing()
+		cancel()
+	})
+
+	nes.Run(ctx, func(f *Frame, err error) {
+		f.Release()
+	})
+
+	if executing {
+		test.Errorf("A command ran partway through an instruction")
+	}
+}
+
+func TestDoAfterRunReturns(test *testing.T) {
+	nes, dir := batteryTestConsole(test)
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	nes.Run(ctx, func(f *Frame, err error) {
+		f.Release()
+	})
+
+	// More calls than there's room to queue, so Do would block if it
+	// were still waiting for Run
+	for i := 0; i <= commandBuffers; i++ {
+		if err := nes.Do(func() {}); err != ErrStopped {
+			test.Fatalf("Do returned %v after Run returned, expected ErrStopped", err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package nes
+
+import (
+	"container/heap"
+	"github.com/scottferg/Fergulator/cpu"
+)
+
+// Region selects the video standard, which fixes how the CPU and
+// PPU clocks divide down from the master clock
+type Region int
+
+const (
+	Ntsc Region = iota
+	Pal
+)
+
+// CpuDivider is the number of master clock cycles per CPU cycle
+func (r Region) CpuDivider() int64 {
+	if r == Pal {
+		return 16
+	}
+
+	return 12
+}
+
+// PpuDivider is the number of master clock cycles per PPU dot
+func (r Region) PpuDivider() int64 {
+	if r == Pal {
+		return 5
+	}
+
+	return 4
+}
+
+// FrameRate is the number of frames shown per second
+func (r Region) FrameRate() float64 {
+	if r == Pal {
+		return 50.0070
+	}
+
+	return 60.0988
+}
+
+// Clocked is a device the scheduler runs alongside the CPU and the
+// PPU, such as an APU or a mapper that counts CPU cycles. Clock is
+// called once for every cycle of the device's own clock.
+type Clocked interface {
+	Clock()
+}
+
+type clockedDevice struct {
+	device  Clocked
+	divider int64
+	next    int64
+}
+
+type event struct {
+	at   int64
+	fire func()
+}
+
+type eventQueue []event
+
+func (q eventQueue) Len() int            { return len(q) }
+func (q eventQueue) Less(i, j int) bool  { return q[i].at < q[j].at }
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(e interface{}) { *q = append(*q, e.(event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return e
+}
+
+// Scheduler keeps the CPU, the PPU and any attached devices on one
+// master clock. Whichever of them is furthest behind runs next, so
+// they advance in lockstep at their real ratios. On a tie events
+// fire first, then the CPU, the PPU and devices run.
+type Scheduler struct {
+	// Master clock cycle the last component ran at
+	Cycles int64
+
+	cpuNext int64
+	ppuNext int64
+	devices []clockedDevice
+	events  eventQueue
+}
+
+// Schedule calls fire once delay master clock cycles have passed
+func (s *Scheduler) Schedule(delay int64, fire func()) {
+	heap.Push(&s.events, event{at: s.Cycles + delay, fire: fire})
+}
+
+// SetRegion switches between NTSC and PAL timing
+func (nes *Console) SetRegion(r Region) {
+	nes.Region = r
+
+	switch r {
+	case Pal:
+		nes.Ppu.LastScanline = 310
+	default:
+		nes.Ppu.LastScanline = 260
+	}
+}
+
+// Attach adds a device clocked once every divider master clock
+// cycles, starting now
+func (nes *Console) Attach(d Clocked, divider int64) {
+	s := &nes.Scheduler
+	s.devices = append(s.devices, clockedDevice{device: d, divider: divider, next: s.Cycles})
+}
+
+// Finds the time of whatever runs next
+func (nes *Console) nextTick() int64 {
+	s := &nes.Scheduler
+	next := s.cpuNext
+
+	if s.ppuNext < next {
+		next = s.ppuNext
+	}
+
+	for _, d := range s.devices {
+		if d.next < next {
+			next = d.next
+		}
+	}
+
+	if len(s.events) > 0 && s.events[0].at < next {
+		next = s.events[0].at
+	}
+
+	return next
+}
+
+// Runs whichever component is furthest behind. The CPU runs a cycle
+// at a time, so it can stop partway through an instruction; done is
+// true when a CPU cycle finished one.
+func (nes *Console) tick() (done bool, err error) {
+	s := &nes.Scheduler
+	s.Cycles = nes.nextTick()
+
+	if len(s.events) > 0 && s.events[0].at == s.Cycles {
+		heap.Pop(&s.events).(event).fire()
+		return
+	}
+
+	if s.cpuNext == s.Cycles {
+		done, err = nes.Cpu.Clock()
+
+		if e, ok := err.(*cpu.InvalidOpcodeError); ok && nes.Rom != nil {
+			e.Bank = nes.Rom.PrgBank(e.ProgramCounter)
+		}
+
+		s.cpuNext += nes.Region.CpuDivider()
+		return
+	}
+
+	if s.ppuNext == s.Cycles {
+		nes.Ppu.Step()
+		s.ppuNext += nes.Region.PpuDivider()
+		return
+	}
+
+	for i := range s.devices {
+		if d := &s.devices[i]; d.next == s.Cycles {
+			d.device.Clock()
+			d.next += d.divider
+			return
+		}
+	}
+
+	return
+}
+
+// Runs everything that's due before master clock cycle t. A jammed
+// CPU doesn't stop the rest of the console, the first error is
+// returned once t is reached.
+func (nes *Console) runUntil(t int64) (err error) {
+	for nes.nextTick() < t {
+		if _, e := nes.tick(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// Step runs the console until the CPU finishes the instruction in
+// progress, or the next one, and returns the number of CPU cycles
+// that took
+func (nes *Console) Step() (cycles int, err error) {
+	start := nes.Cpu.Cycles
+
+	for done := false; !done; {
+		var e error
+		if done, e = nes.tick(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	if e := nes.runUntil(nes.Scheduler.cpuNext); err == nil {
+		err = e
+	}
+
+	return int(nes.Cpu.Cycles - start), err
+}
+
+// RunCycles runs the console for n CPU cycles
+func (nes *Console) RunCycles(n int) error {
+	return nes.runUntil(nes.Scheduler.Cycles + int64(n)*nes.Region.CpuDivider())
+}
+
+// RunScanline runs the console until the PPU starts its next scanline
+func (nes *Console) RunScanline() (err error) {
+	scanline := nes.Ppu.Scanline
+
+	for nes.Ppu.Scanline == scanline {
+		if _, e := nes.tick(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// RunFrame runs the console until the PPU finishes the current frame
+// and returns it. The frame is returned even when err is set, a
+// jammed CPU doesn't stop the PPU.
+//
+// The CPU is left between instructions, where a save state can
+// capture it, so the console runs on for the rest of the instruction
+// the frame ended in.
+func (nes *Console) RunFrame() (f *Frame, err error) {
+	frame := nes.Ppu.FrameCount
+
+	for nes.Ppu.FrameCount == frame || nes.Cpu.Executing() {
+		if _, e := nes.tick(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return nes.finishFrame(), err
+}
@@ -0,0 +1,81 @@
+package nes
+
+import (
+	"testing"
+)
+
+type countingDevice struct {
+	clocks int
+}
+
+func (d *countingDevice) Clock() {
+	d.clocks++
+}
+
+func verifyClockRatio(r Region, cpuCycles int, dots int64, test *testing.T) {
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+	nes.SetRegion(r)
+
+	cpuClocked := new(countingDevice)
+	nes.Attach(cpuClocked, r.CpuDivider())
+
+	nes.RunCycles(cpuCycles)
+
+	if n := nes.Scheduler.ppuNext / r.PpuDivider(); n != dots {
+		test.Errorf("Region %d: %d CPU cycles ran %d PPU dots, expected %d", r, cpuCycles, n, dots)
+	}
+
+	if cpuClocked.clocks != cpuCycles {
+		test.Errorf("Region %d: device was clocked %d times, expected %d", r, cpuClocked.clocks, cpuCycles)
+	}
+}
+
+func TestNtscClockRatio(test *testing.T) {
+	verifyClockRatio(Ntsc, 1000, 3000, test)
+}
+
+func TestPalClockRatio(test *testing.T) {
+	verifyClockRatio(Pal, 1000, 3200, test)
+}
+
+func TestScheduledEvent(test *testing.T) {
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+
+	var firedAt int64 = -1
+	nes.Scheduler.Schedule(1000, func() {
+		firedAt = nes.Scheduler.Cycles
+	})
+
+	nes.RunCycles(50)
+	if firedAt != -1 {
+		test.Errorf("Event fired early at %d", firedAt)
+	}
+
+	nes.RunCycles(50)
+	if firedAt != 1000 {
+		test.Errorf("Event fired at %d, expected 1000", firedAt)
+	}
+}
+
+func TestRunScanlineAndFrame(test *testing.T) {
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+
+	nes.RunScanline()
+	if nes.Ppu.Scanline != 0 {
+		test.Errorf("RunScanline stopped on scanline %d, expected 0", nes.Ppu.Scanline)
+	}
+
+	nes.RunFrame()
+	if nes.Ppu.FrameCount != 1 || nes.Ppu.Scanline != -1 {
+		test.Errorf("RunFrame stopped on frame %d scanline %d", nes.Ppu.FrameCount, nes.Ppu.Scanline)
+	}
+
+	nes.SetRegion(Pal)
+
+	start := nes.Scheduler.ppuNext
+	nes.RunFrame()
+
+	if dots := (nes.Scheduler.ppuNext - start) / Pal.PpuDivider(); dots != 312*341 {
+		test.Errorf("PAL frame ran %d dots, expected %d", dots, 312*341)
+	}
+}
@@ -0,0 +1,44 @@
+package nes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpriteHit(test *testing.T) {
+	roms := []string{
+		"01.basics.nes",
+		"02.alignment.nes",
+		"03.corners.nes",
+		"04.flip.nes",
+		"05.left_clip.nes",
+		"06.right_edge.nes",
+		"07.screen_bottom.nes",
+		"08.double_height.nes",
+		"09.timing_basics.nes",
+		"10.timing_order.nes",
+		"11.edge_timing.nes",
+	}
+
+	for _, rom := range roms {
+		if text := runTestRom("../test_roms/sprite_hit_tests_2005.10.05/"+rom, test); !strings.Contains(text, "PASSED") {
+			test.Errorf("%s failed:\n%s", rom, text)
+		}
+	}
+}
+
+func TestSpriteOverflow(test *testing.T) {
+	roms := []string{
+		"1.Basics.nes",
+		"2.Details.nes",
+		"3.Timing.nes",
+		"4.Obscure.nes",
+		"5.Emulator.nes",
+	}
+
+	for _, rom := range roms {
+		if text := runTestRom("../test_roms/sprite_overflow/sprite_overflow_tests_"+rom, test); !strings.Contains(text, "PASSED") {
+			test.Errorf("%s failed:\n%s", rom, text)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package nes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/scottferg/Fergulator/cartridge"
+	"io/ioutil"
+)
+
+const (
+	// Save states start with this magic, the version of the layout
+	// and the CRC32 of the ROM image they were saved from
+	stateMagic      = "FERG"
+	stateVersion    = 2
+	stateHeaderSize = 9
+	stateSize       = 0x4928 + cartridge.StateSize
+)
+
+// StateError is returned when a save state or battery file can't be
+// loaded into the running console
+type StateError struct {
+	ErrorText string
+}
+
+func (e StateError) Error() string {
+	return e.ErrorText
+}
+
+func (nes *Console) LoadState(filename string) error {
+	state, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if len(state) < stateHeaderSize || string(state[:4]) != stateMagic {
+		return StateError{ErrorText: fmt.Sprintf("%s is not a save state", filename)}
+	}
+
+	if state[4] != stateVersion {
+		return StateError{ErrorText: fmt.Sprintf("%s was saved by a different version", filename)}
+	}
+
+	if binary.BigEndian.Uint32(state[5:stateHeaderSize]) != nes.romHash {
+		return StateError{ErrorText: fmt.Sprintf("%s was saved from a different ROM", filename)}
+	}
+
+	state = state[stateHeaderSize:]
+	if len(state) != stateSize {
+		return StateError{ErrorText: fmt.Sprintf("%s is %d bytes, expected %d", filename, len(state), stateSize)}
+	}
+
+	// Internal RAM is saved with its mirrors, the first copy is
+	// the real one
+	for i, v := range state[:0x800] {
+		nes.Ram.ram[i] = byte(v)
+	}
+
+	pchigh := int(state[0x2000])
+	pclow := int(state[0x2001])
+
+	nes.Cpu.ProgramCounter = (pchigh << 8) | pclow
+
+	nes.Cpu.A = byte(state[0x2002])
+	nes.Cpu.X = byte(state[0x2003])
+	nes.Cpu.Y = byte(state[0x2004])
+	nes.Cpu.P = byte(state[0x2005])
+	nes.Cpu.StackPointer = byte(state[0x2006])
+
+	// Whatever the CPU was in the middle of belongs to the old state
+	nes.Cpu.Abort()
+
+	// Sprite RAM
+	for i, v := range state[0x2007:0x2107] {
+		nes.Ppu.SpriteRam[i] = byte(v)
+	}
+
+	// CHR-RAM, CHR-ROM is read back from the cartridge
+	for i, v := range state[0x2107:0x4107] {
+		nes.Ppu.ChrRam[i] = byte(v)
+	}
+
+	// Nametable VRAM and how it's mirrored
+	nes.Ppu.Nametables.SetMirroring(int(state[0x4107]))
+
+	for i, v := range state[0x4108:0x4508] {
+		nes.Ppu.Nametables.Nametable0[i] = byte(v)
+	}
+	for i, v := range state[0x4508:0x4908] {
+		nes.Ppu.Nametables.Nametable1[i] = byte(v)
+	}
+
+	// Palette RAM
+	for i, v := range state[0x4908:0x4928] {
+		nes.Ppu.PaletteRam[i] = byte(v)
+	}
+
+	// The mapper's registers and the banks it had mapped
+	nes.Rom.LoadState(state[0x4928:])
+
+	return nil
+}
+
+func (nes *Console) SaveState(filename string) error {
+	buf := new(bytes.Buffer)
+
+	buf.WriteString(stateMagic)
+	buf.WriteByte(stateVersion)
+	binary.Write(buf, binary.BigEndian, nes.romHash)
+
+	// RAM
+	for i := 0; i < 0x2000; i++ {
+		buf.WriteByte(nes.Ram.ram[i&0x7FF])
+	}
+
+	// ProgramCounter
+	// High then low
+	buf.WriteByte(byte(nes.Cpu.ProgramCounter>>8) & 0xFF)
+	buf.WriteByte(byte(nes.Cpu.ProgramCounter & 0xFF))
+
+	// CPU Registers
+	buf.WriteByte(byte(nes.Cpu.A))
+	buf.WriteByte(byte(nes.Cpu.X))
+	buf.WriteByte(byte(nes.Cpu.Y))
+	buf.WriteByte(byte(nes.Cpu.P))
+	buf.WriteByte(byte(nes.Cpu.StackPointer))
+
+	// Sprite RAM
+	for _, v := range nes.Ppu.SpriteRam {
+		buf.WriteByte(byte(v))
+	}
+
+	// CHR-RAM
+	for _, v := range nes.Ppu.ChrRam {
+		buf.WriteByte(byte(v))
+	}
+
+	// Nametable VRAM and how it's mirrored
+	buf.WriteByte(byte(nes.Ppu.Nametables.Mirroring))
+
+	for _, v := range nes.Ppu.Nametables.Nametable0 {
+		buf.WriteByte(byte(v))
+	}
+	for _, v := range nes.Ppu.Nametables.Nametable1 {
+		buf.WriteByte(byte(v))
+	}
+
+	// Palette RAM
+	for _, v := range nes.Ppu.PaletteRam {
+		buf.WriteByte(byte(v))
+	}
+
+	// Mapper
+	buf.Write(nes.Rom.SaveState())
+
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+func (nes *Console) LoadBatteryRam(filename string) error {
+	batteryRam, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if len(batteryRam) > 0x2000 {
+		return StateError{ErrorText: fmt.Sprintf("%s is %d bytes, battery RAM is 8k", filename, len(batteryRam))}
+	}
+
+	for i, v := range batteryRam {
+		nes.Ram.prgRam[i] = byte(v)
+	}
+
+	nes.Ram.prgRamDirty = false
+
+	return nil
+}
+
+func (nes *Console) SaveBatteryRam(filename string) error {
+	buf := new(bytes.Buffer)
+
+	// Battery/Work RAM
+	for _, v := range nes.Ram.prgRam {
+		buf.WriteByte(byte(v))
+	}
+
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	nes.Ram.prgRamDirty = false
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package nes
+
+import (
+	"bytes"
+	"github.com/scottferg/Fergulator/ppu"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveStateRoundTrip(test *testing.T) {
+	dir, err := ioutil.TempDir("", "fergulator")
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "nestest.state")
+
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+	nes.Ram.Write(0x0300, 0x42)
+	nes.Cpu.A = 0x24
+
+	if err := nes.SaveState(filename); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	restored := loadTestConsole("../test_roms/nestest.nes", test)
+	if err := restored.LoadState(filename); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	if v := restored.Ram.Read(0x0300); v != 0x42 || restored.Cpu.A != 0x24 {
+		test.Errorf("State was not restored")
+	}
+}
+
+func TestSaveStateMismatch(test *testing.T) {
+	dir, err := ioutil.TempDir("", "fergulator")
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "nestest.state")
+
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+	if err := nes.SaveState(filename); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	other := loadTestConsole("../test_roms/nesstress.nes", test)
+	if _, ok := other.LoadState(filename).(StateError); !ok {
+		test.Errorf("State from another ROM was loaded")
+	}
+
+	ioutil.WriteFile(filename, []byte("garbage"), 0644)
+	if _, ok := nes.LoadState(filename).(StateError); !ok {
+		test.Errorf("Corrupt state was loaded")
+	}
+}
+
+// Reads the pattern tables through $2006 and $2007
+func dumpChr(nes *Console) []byte {
+	nes.Ppu.WriteAddress(0x00)
+	nes.Ppu.WriteAddress(0x00)
+	nes.Ppu.ReadData()
+
+	chr := make([]byte, 0x2000)
+	for i := range chr {
+		chr[i] = nes.Ppu.ReadData()
+	}
+
+	return chr
+}
+
+func TestSaveStateMapperBanks(test *testing.T) {
+	dir, err := ioutil.TempDir("", "fergulator")
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "mmc3.state")
+	rom := "../test_roms/mmc3_test_2/rom_singles/1-clocking.nes"
+
+	// Swap the 8k PRG bank at $8000 and the CHR banks at $0000 and
+	// $1C00 on an MMC3, and mirror horizontally
+	nes := loadTestConsole(rom, test)
+	for _, w := range [][2]byte{{0x06, 0x02}, {0x00, 0x06}, {0x05, 0x03}} {
+		nes.Ram.Write(0x8000, w[0])
+		nes.Ram.Write(0x8001, w[1])
+	}
+	nes.Ram.Write(0xA000, 0x01)
+
+	if err := nes.SaveState(filename); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	restored := loadTestConsole(rom, test)
+	if err := restored.LoadState(filename); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	if b := restored.Rom.PrgBank(0x8000); b != 2 {
+		test.Errorf("$8000 held PRG bank %d, expected 2", b)
+	}
+
+	for a := 0x8000; a < 0x10000; a += 0x100 {
+		if restored.Ram.Read(uint16(a)) != nes.Ram.Read(uint16(a)) {
+			test.Errorf("PRG at 0x%X wasn't restored", a)
+			break
+		}
+	}
+
+	if !bytes.Equal(dumpChr(restored), dumpChr(nes)) {
+		test.Errorf("CHR banks weren't restored")
+	}
+
+	if restored.Ppu.Nametables.Mirroring != ppu.MirroringHorizontal {
+		test.Errorf("Mirroring wasn't restored")
+	}
+}
+
+func TestLoadStateAbortsInstruction(test *testing.T) {
+	dir, err := ioutil.TempDir("", "fergulator")
+	if err != nil {
+		test.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "nestest.state")
+
+	nes := loadTestConsole("../test_roms/nestest.nes", test)
+	if err := nes.SaveState(filename); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	// Start the first instruction, then load over it
+	nes.Cpu.Clock()
+
+	if err := nes.LoadState(filename); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	if nes.Cpu.Executing() {
+		test.Errorf("The CPU carried on with an instruction from before the load")
+	}
+}
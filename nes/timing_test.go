@@ -0,0 +1,139 @@
+package nes
+
+import (
+	"github.com/scottferg/Fergulator/input"
+	"strings"
+	"testing"
+)
+
+// Runs one of blargg's test ROMs, holding down buttons from power on,
+// until it parks the CPU on a jump to itself. Returns what the ROM
+// printed to the first nametable.
+func runTestRom(path string, test *testing.T, buttons ...int) string {
+	nes := loadTestConsole(path, test)
+
+	for _, b := range buttons {
+		nes.Controller.ButtonDown(b)
+	}
+
+	for frame := 0; frame < 60*30; frame++ {
+		f, err := nes.RunFrame()
+		if err != nil {
+			test.Fatal(err.Error())
+		}
+		f.Release()
+
+		pc := uint16(nes.Cpu.ProgramCounter)
+		target := uint16(nes.Ram.Read(pc+2))<<8 | uint16(nes.Ram.Read(pc+1))
+
+		if nes.Ram.Read(pc) == 0x4C && target == pc {
+			return screenText(nes)
+		}
+	}
+
+	test.Fatalf("%s never finished: %q", path, screenText(nes))
+	return ""
+}
+
+func screenText(nes *Console) string {
+	var lines []string
+
+	for row := 0; row < 30; row++ {
+		line := make([]byte, 32)
+		for col := range line {
+			line[col] = nes.Ppu.Nametables.ReadNametableData(0x2000 + row*32 + col)
+			if line[col] < ' ' || line[col] > '~' {
+				line[col] = ' '
+			}
+		}
+
+		if l := strings.TrimSpace(string(line)); l != "" {
+			lines = append(lines, l)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func TestCpuTiming(test *testing.T) {
+	if testing.Short() {
+		test.Skip("takes 16 seconds of emulated time")
+	}
+
+	// Holding B adds the unofficial instructions
+	modes := map[string][]int{
+		"official":   nil,
+		"unofficial": {input.B},
+	}
+
+	for mode, buttons := range modes {
+		if text := runTestRom("../test_roms/cpu_timing_test6/cpu_timing_test.nes", test, buttons...); !strings.Contains(text, "PASSED") {
+			test.Errorf("cpu_timing_test failed with %s instructions:\n%s", mode, text)
+		}
+	}
+}
+
+// Runs a test ROM that reports through PRG-RAM, until it writes its
+// result to $6000. Returns the result and the text at $6004.
+func runStatusRom(path string, test *testing.T) (byte, string) {
+	nes := loadTestConsole(path, test)
+
+	for frame := 0; frame < 60*120; frame++ {
+		f, err := nes.RunFrame()
+		if err != nil {
+			test.Fatal(err.Error())
+		}
+		f.Release()
+
+		// $6001-$6003 hold DE B0 61 once $6000 means anything
+		if nes.Ram.Read(0x6001) != 0xDE || nes.Ram.Read(0x6002) != 0xB0 || nes.Ram.Read(0x6003) != 0x61 {
+			continue
+		}
+
+		switch status := nes.Ram.Read(0x6000); status {
+		case 0x80:
+			// Still running
+		case 0x81:
+			nes.Reset()
+		default:
+			return status, statusText(nes)
+		}
+	}
+
+	test.Fatalf("%s never finished: %q", path, statusText(nes))
+	return 0, ""
+}
+
+func statusText(nes *Console) string {
+	var text []byte
+
+	for a := uint16(0x6004); a < 0x7000 && nes.Ram.Read(a) != 0; a++ {
+		text = append(text, nes.Ram.Read(a))
+	}
+
+	return strings.TrimSpace(string(text))
+}
+
+func TestAllInstructions(test *testing.T) {
+	if testing.Short() {
+		test.Skip("takes around 10 seconds to run")
+	}
+
+	if status, text := runStatusRom("../test_roms/blargg_cpu/all_instrs.nes", test); status != 0 {
+		test.Errorf("all_instrs failed with status %d:\n%s", status, text)
+	}
+}
+
+func TestBranchTiming(test *testing.T) {
+	roms := []string{
+		"1.Branch_Basics.nes",
+		"2.Backward_Branch.nes",
+		"3.Forward_Branch.nes",
+	}
+
+	for _, rom := range roms {
+		if text := runTestRom("../test_roms/branch_timing_tests/"+rom, test); !strings.Contains(text, "PASSED") {
+			test.Errorf("%s failed:\n%s", rom, text)
+		}
+	}
+}
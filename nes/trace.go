@@ -0,0 +1,258 @@
+package nes
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/scottferg/Fergulator/cpu"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// TraceFormat is the layout of the lines a Tracer writes
+type TraceFormat int
+
+const (
+	// The layout of test_roms/nestest.log, which a trace of nestest
+	// matches line for line. It has no CPU cycle or bank columns.
+	TraceNestest TraceFormat = iota
+	TraceFceux
+	TraceMesen
+)
+
+var traceFormats = map[string]TraceFormat{
+	"nestest": TraceNestest,
+	"fceux":   TraceFceux,
+	"mesen":   TraceMesen,
+}
+
+// ParseTraceFormat looks up a format by its lowercase name
+func ParseTraceFormat(name string) (TraceFormat, error) {
+	if f, ok := traceFormats[name]; ok {
+		return f, nil
+	}
+
+	return 0, fmt.Errorf("Unknown trace format %q, expected nestest, fceux or mesen", name)
+}
+
+// Tracer writes a line for every instruction the CPU starts, with the
+// registers as they are before it runs. It's attached to a console
+// with SetTracer.
+type Tracer struct {
+	Format TraceFormat
+
+	// Only instructions from Start through End are logged. An End of
+	// zero logs every address.
+	Start int
+	End   int
+
+	// Only instructions in this 8k PRG bank are logged, -1 logs code
+	// wherever it runs from
+	Bank int
+
+	w       *bufio.Writer
+	enabled int32
+	err     error
+}
+
+// NewTracer returns an enabled tracer writing to w, with no filters
+func NewTracer(w io.Writer, format TraceFormat) *Tracer {
+	return &Tracer{
+		Format:  format,
+		Bank:    -1,
+		w:       bufio.NewWriter(w),
+		enabled: 1,
+	}
+}
+
+// SetEnabled starts or pauses logging. It's safe to call while the
+// console is running on another goroutine.
+func (t *Tracer) SetEnabled(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+
+	atomic.StoreInt32(&t.enabled, v)
+}
+
+func (t *Tracer) Enabled() bool {
+	return atomic.LoadInt32(&t.enabled) == 1
+}
+
+// Flush writes out any buffered lines, and returns the first error
+// writing them hit
+func (t *Tracer) Flush() error {
+	if err := t.w.Flush(); err != nil && t.err == nil {
+		t.err = err
+	}
+
+	return t.err
+}
+
+// SetTracer logs instructions to t, or stops logging when t is nil
+func (nes *Console) SetTracer(t *Tracer) {
+	nes.tracer = t
+
+	if t == nil {
+		nes.Cpu.Trace = nil
+		return
+	}
+
+	nes.Cpu.Trace = func(c *cpu.Cpu) {
+		nes.trace(t)
+	}
+}
+
+// ToggleTrace pauses or resumes the tracer, and reports whether it's
+// now logging. It's false when there's no tracer.
+func (nes *Console) ToggleTrace() bool {
+	t := nes.tracer
+	if t == nil {
+		return false
+	}
+
+	t.SetEnabled(!t.Enabled())
+	return t.Enabled()
+}
+
+func (nes *Console) trace(t *Tracer) {
+	if !t.Enabled() || t.err != nil {
+		return
+	}
+
+	c := nes.Cpu
+	pc := c.ProgramCounter
+
+	if t.End != 0 && (pc < t.Start || pc > t.End) {
+		return
+	}
+
+	bank := -1
+	if nes.Rom != nil {
+		bank = nes.Rom.PrgBank(pc)
+	}
+
+	if t.Bank != -1 && bank != t.Bank {
+		return
+	}
+
+	i := cpu.Lookup(nes.Ram.Peek(uint16(pc)))
+
+	var bytes []string
+	operand := 0
+
+	for n := 0; n < i.Size; n++ {
+		b := nes.Ram.Peek(uint16(pc + n))
+		bytes = append(bytes, fmt.Sprintf("%02X", b))
+
+		if n > 0 {
+			operand |= int(b) << (8 * uint(n-1))
+		}
+	}
+
+	text := i.Name
+	if o := i.Operand(operand, pc); o != "" {
+		text += " " + o + nes.annotate(i, operand)
+	}
+
+	unofficial := ' '
+	if !i.Official {
+		unofficial = '*'
+	}
+
+	// The fetch cycle has already been counted
+	cycles := c.Cycles - 1
+	dot := nes.Ppu.Cycle - 1
+
+	var err error
+
+	switch t.Format {
+	case TraceNestest:
+		_, err = fmt.Fprintf(t.w, "%04X  %-8s %c%-32sA:%02X X:%02X Y:%02X P:%02X SP:%02X CYC:%3d SL:%d\n",
+			pc, strings.Join(bytes, " "), unofficial, text,
+			c.A, c.X, c.Y, c.P, c.StackPointer, dot, nes.Ppu.Scanline)
+	case TraceFceux:
+		_, err = fmt.Fprintf(t.w, "c%-11d A:%02X X:%02X Y:%02X S:%02X P:%s  %s%04X: %-8s %c%s\n",
+			cycles, c.A, c.X, c.Y, c.StackPointer, flagString(c.P), bankPrefix(bank), pc,
+			strings.Join(bytes, " "), unofficial, text)
+	case TraceMesen:
+		_, err = fmt.Fprintf(t.w, "%s%04X  $%-12s %c%-31s A:%02X X:%02X Y:%02X P:%02X SP:%02X CYC:%-3d SL:%-3d CPU Cycle:%d\n",
+			bankPrefix(bank), pc, strings.Join(bytes, " $"), unofficial, text,
+			c.A, c.X, c.Y, c.P, c.StackPointer, dot, nes.Ppu.Scanline, cycles)
+	}
+
+	t.err = err
+}
+
+// Follows the operand to the memory it points at, the way nestest.log
+// shows it
+func (nes *Console) annotate(i cpu.Instruction, operand int) string {
+	c := nes.Cpu
+
+	peek := func(a int) int {
+		return int(nes.Ram.Peek(uint16(a)))
+	}
+
+	// Pointers in the zero page wrap around within it
+	pointer := func(a int) int {
+		return peek(a&0xFF) | peek((a+1)&0xFF)<<8
+	}
+
+	switch i.Mode {
+	case cpu.ZeroPage:
+		return fmt.Sprintf(" = %02X", peek(operand))
+	case cpu.ZeroPageX:
+		a := (operand + int(c.X)) & 0xFF
+		return fmt.Sprintf(" @ %02X = %02X", a, peek(a))
+	case cpu.ZeroPageY:
+		a := (operand + int(c.Y)) & 0xFF
+		return fmt.Sprintf(" @ %02X = %02X", a, peek(a))
+	case cpu.Absolute:
+		if i.Name == "JMP" || i.Name == "JSR" {
+			return ""
+		}
+
+		return fmt.Sprintf(" = %02X", peek(operand))
+	case cpu.AbsoluteX:
+		a := (operand + int(c.X)) & 0xFFFF
+		return fmt.Sprintf(" @ %04X = %02X", a, peek(a))
+	case cpu.AbsoluteY:
+		a := (operand + int(c.Y)) & 0xFFFF
+		return fmt.Sprintf(" @ %04X = %02X", a, peek(a))
+	case cpu.Indirect:
+		// nestest.log shows the target without JMP's page wrap bug
+		return fmt.Sprintf(" = %04X", peek(operand)|peek(operand+1)<<8)
+	case cpu.IndirectX:
+		p := (operand + int(c.X)) & 0xFF
+		a := pointer(p)
+		return fmt.Sprintf(" @ %02X = %04X = %02X", p, a, peek(a))
+	case cpu.IndirectY:
+		base := pointer(operand)
+		a := (base + int(c.Y)) & 0xFFFF
+		return fmt.Sprintf(" = %04X @ %04X = %02X", base, a, peek(a))
+	}
+
+	return ""
+}
+
+// Flags set are shown in upper case, clear ones in lower case
+func flagString(p byte) string {
+	flags := []byte("nvubdizc")
+
+	for i := range flags {
+		if p&(0x80>>uint(i)) != 0 {
+			flags[i] -= 'a' - 'A'
+		}
+	}
+
+	return string(flags)
+}
+
+func bankPrefix(bank int) string {
+	if bank < 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%02X:", bank)
+}
@@ -0,0 +1,89 @@
+package nes
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTraceMatchesGoldLog(test *testing.T) {
+	nes, log := loadGoldLog(test)
+
+	var trace bytes.Buffer
+	t := NewTracer(&trace, TraceNestest)
+	nes.SetTracer(t)
+
+	for i := 0; i < len(log) && log[i] != ""; i++ {
+		nes.Step()
+	}
+
+	if err := t.Flush(); err != nil {
+		test.Fatal(err.Error())
+	}
+
+	lines := strings.Split(trace.String(), "\n")
+
+	for i := 0; i < len(log) && log[i] != ""; i++ {
+		if expected := strings.TrimRight(log[i], "\r"); lines[i] != expected {
+			test.Fatalf("Trace diverged from nestest.log at line %d\nexpected %q\n     got %q", i+1, expected, lines[i])
+		}
+	}
+}
+
+func TestTraceFilters(test *testing.T) {
+	nes, _ := loadGoldLog(test)
+
+	var trace bytes.Buffer
+	t := NewTracer(&trace, TraceMesen)
+	t.Start = 0xC5F5
+	t.End = 0xC5FF
+	nes.SetTracer(t)
+
+	traced := func(steps int) []string {
+		trace.Reset()
+		for i := 0; i < steps; i++ {
+			nes.Step()
+		}
+		t.Flush()
+
+		if trace.Len() == 0 {
+			return nil
+		}
+
+		return strings.Split(strings.TrimRight(trace.String(), "\n"), "\n")
+	}
+
+	// Starts with the JMP at $C000 and ends up in the JSR at $C5FD
+	lines := traced(10)
+	if len(lines) != 5 {
+		test.Fatalf("Traced %d lines between $C5F5 and $C5FF, expected 5:\n%s", len(lines), trace.String())
+	}
+
+	if !strings.HasPrefix(trace.String(), "00:C5F5  $A2 $00") || !strings.HasSuffix(lines[0], "CPU Cycle:3") {
+		test.Errorf("Unexpected first line %q", lines[0])
+	}
+
+	t.Start = 0
+	t.End = 0
+
+	if nes.ToggleTrace() {
+		test.Fatalf("ToggleTrace didn't pause the tracer")
+	}
+
+	if lines = traced(10); len(lines) != 0 {
+		test.Errorf("Traced %d lines while paused", len(lines))
+	}
+
+	nes.ToggleTrace()
+
+	// nestest runs from the first 8k of its PRG
+	t.Bank = 1
+	if lines = traced(10); len(lines) != 0 {
+		test.Errorf("Traced %d lines from outside bank 1", len(lines))
+	}
+
+	t.Bank = 0
+	if lines = traced(10); len(lines) != 10 {
+		test.Errorf("Traced %d lines from bank 0, expected 10", len(lines))
+	}
+}
@@ -0,0 +1,142 @@
+package ppu
+
+// The background is drawn a dot at a time, the way the 2C02 draws it,
+// so writes to $2000, $2001, $2005 and $2006 partway through a
+// scanline take effect from the next dot or tile on.
+//
+// Every tile takes eight dots: two each to fetch its nametable byte,
+// attribute byte and the two planes of its pattern, then coarse X
+// moves on to the next tile. The tile is loaded into the low half of
+// the shift registers once the eight dots are up, and shifts out of
+// the high half one pixel a dot while the next one is fetched. The
+// first two tiles of a scanline are fetched at the end of the one
+// before it.
+//
+// http://wiki.nesdev.com/w/index.php/PPU_rendering
+// http://wiki.nesdev.com/w/index.php/PPU_scrolling
+type background struct {
+	tile      byte
+	attribute byte
+	low       byte
+	high      byte
+}
+
+func (p *Ppu) renderingEnabled() bool {
+	return p.ShowBackground || p.ShowSprites
+}
+
+// Runs the background for the current dot of the pre-render or a
+// visible scanline
+func (p *Ppu) renderBackground() {
+	dot := p.Cycle - 1
+
+	if p.renderingEnabled() {
+		if dot >= 2 && dot <= 257 || dot >= 322 && dot <= 337 {
+			p.shiftBackground()
+
+			if (dot-1)&0x7 == 0 {
+				p.reloadBackground()
+			}
+		}
+
+		if dot >= 1 && dot <= 256 || dot >= 321 && dot <= 336 {
+			p.fetchBackground((dot - 1) & 0x7)
+		}
+
+		switch {
+		case dot == 256:
+			p.incrementFineY()
+		case dot == 257:
+			// Copy coarse X and the horizontal nametable bit
+			p.VramAddress = p.VramAddress&^0x41F | p.VramLatch&0x41F
+		case dot >= 280 && dot <= 304 && p.Scanline == -1:
+			// Copy fine Y, coarse Y and the vertical nametable bit
+			p.VramAddress = p.VramAddress&^0x7BE0 | p.VramLatch&0x7BE0
+		}
+	}
+}
+
+func (p *Ppu) fetchBackground(step int) {
+	switch step {
+	case 0:
+		p.bg.tile = p.Nametables.ReadNametableData(0x2000 | p.VramAddress&0xFFF)
+	case 2:
+		attrAddr := 0x23C0 | (p.VramAddress & 0xC00) | int(p.AttributeLocation[p.VramAddress&0x3FF])
+		shift := p.AttributeShift[p.VramAddress&0x3FF]
+		p.bg.attribute = (p.Nametables.ReadNametableData(attrAddr) >> shift) & 0x03
+	case 4:
+		p.bg.low = p.readChr(p.bgPatternTableAddress(p.bg.tile))
+	case 6:
+		p.bg.high = p.readChr(p.bgPatternTableAddress(p.bg.tile) + 8)
+	case 7:
+		p.incrementCoarseX()
+	}
+}
+
+func (p *Ppu) shiftBackground() {
+	p.LowBitShift <<= 1
+	p.HighBitShift <<= 1
+	p.AttributeLowShift <<= 1
+	p.AttributeHighShift <<= 1
+}
+
+// The attribute bits are spread across all eight pixels of the tile
+func (p *Ppu) reloadBackground() {
+	p.LowBitShift = p.LowBitShift&0xFF00 | uint16(p.bg.low)
+	p.HighBitShift = p.HighBitShift&0xFF00 | uint16(p.bg.high)
+
+	p.AttributeLowShift &= 0xFF00
+	if p.bg.attribute&0x1 != 0 {
+		p.AttributeLowShift |= 0xFF
+	}
+
+	p.AttributeHighShift &= 0xFF00
+	if p.bg.attribute&0x2 != 0 {
+		p.AttributeHighShift |= 0xFF
+	}
+}
+
+// The background pixel at x, drawn from the bit of the shift registers
+// that fine X picks. It's 0 where the background is hidden or clipped.
+func (p *Ppu) backgroundPixel(x int) (pixel uint16, attr byte) {
+	if !p.ShowBackground || x < 8 && !p.ShowBackgroundOnLeft {
+		return 0, 0
+	}
+
+	bit := 15 - uint(p.FineX)
+
+	pixel = (p.LowBitShift>>bit)&0x1 | (p.HighBitShift>>bit)&0x1<<1
+	attr = byte((p.AttributeLowShift>>bit)&0x1|(p.AttributeHighShift>>bit)&0x1<<1) << 2
+
+	return pixel, attr
+}
+
+// Flip bit 10 on wraparound
+func (p *Ppu) incrementCoarseX() {
+	if p.VramAddress&0x1F == 0x1F {
+		p.VramAddress ^= 0x41F
+	} else {
+		p.VramAddress++
+	}
+}
+
+// Fine Y carries into coarse Y, which wraps to the next nametable down
+// after row 29. Rows 30 and 31 hold the attributes, a coarse Y set
+// there by a write wraps to 0 without changing nametable.
+func (p *Ppu) incrementFineY() {
+	if p.VramAddress&0x7000 != 0x7000 {
+		p.VramAddress += 0x1000
+		return
+	}
+
+	p.VramAddress &^= 0x7000
+
+	switch p.VramAddress & 0x3E0 {
+	case 0x3A0:
+		p.VramAddress ^= 0xBA0
+	case 0x3E0:
+		p.VramAddress ^= 0x3E0
+	default:
+		p.VramAddress += 0x20
+	}
+}
@@ -0,0 +1,154 @@
+package ppu
+
+import (
+	"testing"
+)
+
+type nullMapper struct{}
+
+func (m nullMapper) Hook() {}
+
+// A PPU at the start of the pre-render scanline with the background
+// showing. Tile 1 is solid color 1 and tile 2 solid color 2.
+func newRenderTestPpu() *Ppu {
+	p := new(Ppu)
+	p.Init()
+	p.Rom = nullMapper{}
+	p.Nametables.SetMirroring(MirroringVertical)
+
+	for row := 0; row < 8; row++ {
+		p.ChrRam[0x10+row] = 0xFF
+		p.ChrRam[0x28+row] = 0xFF
+	}
+
+	p.WriteMask(0x0A)
+	p.Cycle = 1
+
+	return p
+}
+
+func fillNametable(p *Ppu, a int, tile func(row, col int) byte) {
+	for i := 0; i < 960; i++ {
+		p.Nametables.WriteNametableData(a+i, tile(i/32, i%32))
+	}
+}
+
+// Runs the PPU until it's about to draw dot of scanline
+func runToDot(p *Ppu, scanline, dot int) {
+	for p.Scanline != scanline || p.Cycle-1 != dot {
+		p.Step()
+	}
+}
+
+func verifyRow(p *Ppu, line int, expected func(x int) int, test *testing.T) {
+	for x := 0; x < 256; x++ {
+		if v := p.Palettebuffer[line*256+x].Value; v != expected(x) {
+			test.Errorf("Pixel %d of scanline %d was %d, expected %d", x, line, v, expected(x))
+			return
+		}
+	}
+}
+
+func TestBackgroundFineScroll(test *testing.T) {
+	p := newRenderTestPpu()
+
+	// Odd columns are tile 2, even ones tile 1
+	columns := func(row, col int) byte {
+		return byte(1 + col%2)
+	}
+
+	fillNametable(p, 0x2000, columns)
+	fillNametable(p, 0x2400, columns)
+
+	// Scroll 3 pixels right and 21 down
+	p.WriteScroll(3)
+	p.WriteScroll(21)
+
+	runToDot(p, 0, 0)
+
+	if v := p.VramAddress & 0x7BE0; v != 0x5040 {
+		test.Errorf("Pre-render scanline left the vertical scroll at %04X, expected 5040", v)
+	}
+
+	runToDot(p, 1, 0)
+
+	verifyRow(p, 0, func(x int) int {
+		return 1 + ((x+3)/8)%2
+	}, test)
+
+	if v := p.VramAddress & 0x7BE0; v != 0x6040 {
+		test.Errorf("Scanline 0 left the vertical scroll at %04X, expected 6040", v)
+	}
+}
+
+func TestBackgroundFineYCarries(test *testing.T) {
+	p := newRenderTestPpu()
+
+	// Row 2 is tile 1, row 3 tile 2
+	fillNametable(p, 0x2000, func(row, col int) byte {
+		return byte(row - 1)
+	})
+
+	// Line 2 is the last of row 2, line 3 the first of row 3
+	p.WriteScroll(0)
+	p.WriteScroll(21)
+
+	runToDot(p, 4, 0)
+
+	verifyRow(p, 2, func(x int) int { return 1 }, test)
+	verifyRow(p, 3, func(x int) int { return 2 }, test)
+}
+
+func TestMidScanlineAddressWrite(test *testing.T) {
+	p := newRenderTestPpu()
+
+	fillNametable(p, 0x2000, func(row, col int) byte { return 1 })
+	fillNametable(p, 0x2400, func(row, col int) byte { return 2 })
+
+	p.WriteScroll(0)
+	p.WriteScroll(0)
+
+	// Point the PPU at $2400 once it's moved on from the tile it
+	// fetched during dots 121-128. That tile and the one before it are
+	// already in the shift registers, so the change shows from pixel
+	// 144.
+	runToDot(p, 0, 129)
+	p.WriteAddress(0x24)
+	p.WriteAddress(0x00)
+
+	runToDot(p, 2, 0)
+
+	verifyRow(p, 0, func(x int) int {
+		if x < 144 {
+			return 1
+		}
+
+		return 2
+	}, test)
+
+	// The write also set the horizontal nametable bit of the latch,
+	// which is copied back at dot 257
+	verifyRow(p, 1, func(x int) int { return 2 }, test)
+}
+
+func TestMidScanlineMaskWrite(test *testing.T) {
+	p := newRenderTestPpu()
+
+	fillNametable(p, 0x2000, func(row, col int) byte { return 1 })
+
+	p.WriteScroll(0)
+	p.WriteScroll(0)
+
+	runToDot(p, 0, 65)
+	p.WriteMask(0x00)
+
+	runToDot(p, 1, 0)
+
+	verifyRow(p, 0, func(x int) int {
+		if x < 64 {
+			return 1
+		}
+
+		return 0
+	}, test)
+}
@@ -1,4 +1,4 @@
-package main
+package ppu
 
 const (
 	MirroringVertical = iota
@@ -9,9 +9,9 @@ const (
 
 type Nametable struct {
 	Mirroring     int
-	LogicalTables [4]*[0x400]Word
-	Nametable0    [0x400]Word
-	Nametable1    [0x400]Word
+	LogicalTables [4]*[0x400]byte
+	Nametable0    [0x400]byte
+	Nametable1    [0x400]byte
 }
 
 func (n *Nametable) SetMirroring(m int) {
@@ -41,10 +41,10 @@ func (n *Nametable) SetMirroring(m int) {
 	}
 }
 
-func (n *Nametable) writeNametableData(a int, v Word) {
-    n.LogicalTables[(a&0xC00)>>10][a&0x3FF] = v
+func (n *Nametable) WriteNametableData(a int, v byte) {
+	n.LogicalTables[(a&0xC00)>>10][a&0x3FF] = v
 }
 
-func (n *Nametable) readNametableData(a int) Word {
+func (n *Nametable) ReadNametableData(a int) byte {
 	return n.LogicalTables[(a&0xC00)>>10][a&0x3FF]
 }
@@ -0,0 +1,120 @@
+package ppu
+
+import (
+	"math"
+)
+
+// Settings for GeneratePalette. Hue turns the decoded colors, in
+// degrees. Saturation and Contrast scale them and Brightness is added.
+// DefaultNtsc leaves the signal as the PPU puts it out.
+type NtscSettings struct {
+	Hue        float64
+	Saturation float64
+	Contrast   float64
+	Brightness float64
+}
+
+var DefaultNtsc = NtscSettings{
+	Saturation: 1,
+	Contrast:   1,
+}
+
+// Voltages of the composite signal the 2C02 puts out, low and high for
+// each of the four luma levels, relative to blank
+var (
+	ntscLow   = [4]float64{0.228, 0.312, 0.552, 0.880}
+	ntscHigh  = [4]float64{0.616, 0.840, 1.100, 1.100}
+	ntscBlack = 0.312
+	ntscWhite = 1.100
+)
+
+const (
+	// Emphasized phases of the signal are attenuated to about three
+	// quarters
+	ntscAttenuation = 0.746
+
+	// Brings the chroma of a square wave up to that of the sine wave a
+	// TV expects
+	ntscChromaGain = 1.7
+)
+
+// GeneratePalette decodes the composite signal for each of the 512
+// colors the PPU draws, the way a TV would.
+//
+// A color is a square wave at the color subcarrier frequency, twelve
+// phases long. Its hue picks which six phases are high, 0 is high all
+// the way round and $D-$F are low all the way round. The emphasis bits
+// attenuate the phases a third of the way round from one another.
+//
+// http://wiki.nesdev.com/w/index.php/NTSC_video
+func GeneratePalette(s NtscSettings) []uint32 {
+	palette := make([]uint32, 512)
+
+	for c := range palette {
+		level, hue := c>>4&0x3, c&0x0F
+
+		// $xE and $xF are the black of level 1
+		if hue > 0x0D {
+			level = 1
+		}
+
+		low, high := ntscLow[level], ntscHigh[level]
+		if hue == 0 {
+			low = high
+		}
+
+		if hue > 0x0C {
+			high = low
+		}
+
+		var y, i, q float64
+
+		for phase := 0; phase < 12; phase++ {
+			v := low
+			if inColorPhase(hue, phase) {
+				v = high
+			}
+
+			if hue < 0x0E && (c&0x40 != 0 && inColorPhase(0xC, phase) ||
+				c&0x80 != 0 && inColorPhase(0x4, phase) ||
+				c&0x100 != 0 && inColorPhase(0x8, phase)) {
+				v *= ntscAttenuation
+			}
+
+			v = (v - ntscBlack) / (ntscWhite - ntscBlack) / 12
+
+			// Lined up so color $8 is in phase with the color burst
+			a := math.Pi * (float64(phase) + 4) / 6
+			y += v
+			i += v * math.Cos(a)
+			q += v * math.Sin(a)
+		}
+
+		palette[c] = ntscRgb(y, i, q, s)
+	}
+
+	return palette
+}
+
+func inColorPhase(hue, phase int) bool {
+	return (hue+phase)%12 < 6
+}
+
+// Applies the settings to a decoded YIQ color and converts it to RGB
+func ntscRgb(y, i, q float64, s NtscSettings) uint32 {
+	y = y*s.Contrast + s.Brightness
+
+	h := s.Hue * math.Pi / 180
+	i, q = i*math.Cos(h)-q*math.Sin(h), i*math.Sin(h)+q*math.Cos(h)
+	i, q = i*s.Saturation*ntscChromaGain, q*s.Saturation*ntscChromaGain
+
+	r := y + 0.956*i + 0.621*q
+	g := y - 0.272*i - 0.647*q
+	b := y - 1.106*i + 1.703*q
+
+	return ntscChannel(r)<<16 | ntscChannel(g)<<8 | ntscChannel(b)
+}
+
+func ntscChannel(v float64) uint32 {
+	return uint32(math.Max(0, math.Min(255, math.Floor(v*255+0.5))))
+}
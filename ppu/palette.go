@@ -0,0 +1,56 @@
+package ppu
+
+var (
+	PaletteRgb = []uint32{
+		0x7C7C7C, 0x0000FC, 0x0000BC, 0x4428BC, 0x940084,
+		0xA80020, 0xA81000, 0x881400, 0x503000, 0x007800,
+		0x006800, 0x005800, 0x004058, 0x000000, 0x000000,
+		0x000000, 0xBCBCBC, 0x0078F8, 0x0058F8, 0x6844FC,
+		0xD800CC, 0xE40058, 0xF83800, 0xE45C10, 0xAC7C00,
+		0x00B800, 0x00A800, 0x00A844, 0x008888, 0x000000,
+		0x000000, 0x000000, 0xF8F8F8, 0x3CBCFC, 0x6888FC,
+		0x9878F8, 0xF878F8, 0xF85898, 0xF87858, 0xFCA044,
+		0xF8B800, 0xB8F818, 0x58D854, 0x58F898, 0x00E8D8,
+		0x787878, 0x000000, 0x000000, 0xFCFCFC, 0xA4E4FC,
+		0xB8B8F8, 0xD8B8F8, 0xF8B8F8, 0xF8A4C0, 0xF0D0B0,
+		0xFCE0A8, 0xF8D878, 0xD8F878, 0xB8F8B8, 0xB8F8D8,
+		0x00FCFC, 0xF8D8F8, 0x000000, 0x000000,
+	}
+)
+
+// PaletteRgb with every combination of the emphasis bits, which each
+// PPU starts out with. It's never written to.
+var defaultPalette = emphasize(PaletteRgb)
+
+// Expands 64 colors to the 512 the PPU draws, indexed by the palette
+// entry in bits 0-5 and blue, green and red emphasis in bits 8-6.
+// Emphasizing a color darkens the channels that aren't emphasized.
+// Columns $xE and $xF are black and stay that way.
+func emphasize(rgb []uint32) []uint32 {
+	const dim = 0.75
+
+	palette := make([]uint32, 512)
+
+	for i := range palette {
+		c := rgb[i&0x3F]
+		r, g, b := float64(c>>16&0xFF), float64(c>>8&0xFF), float64(c&0xFF)
+
+		if i&0x0E != 0x0E {
+			if i&0x40 != 0 {
+				g, b = g*dim, b*dim
+			}
+
+			if i&0x80 != 0 {
+				r, b = r*dim, b*dim
+			}
+
+			if i&0x100 != 0 {
+				r, g = r*dim, g*dim
+			}
+		}
+
+		palette[i] = uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+	}
+
+	return palette
+}
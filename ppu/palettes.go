@@ -0,0 +1,132 @@
+package ppu
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+// Palettes that can be picked by name with BuiltinPalette, each with
+// all 512 colors
+var builtinPalettes = map[string][]uint32{
+	// Decoded from the composite signal of the NTSC PPU
+	"2c02": GeneratePalette(DefaultNtsc),
+
+	// The RGB PPU of the PlayChoice-10 and Famicom Titler, which puts
+	// out three bits a channel
+	"2c03": emphasizeRgb(rgbPpuPalette()),
+
+	"fceux":    emphasize(fceuxPalette),
+	"nestopia": emphasize(nestopiaPalette),
+}
+
+// BuiltinPalette returns a copy of one of the palettes built in: 2c02,
+// 2c03, fceux or nestopia.
+func BuiltinPalette(name string) ([]uint32, error) {
+	if p, ok := builtinPalettes[name]; ok {
+		return append([]uint32(nil), p...), nil
+	}
+
+	return nil, fmt.Errorf("Unknown palette %q, expected 2c02, 2c03, fceux or nestopia", name)
+}
+
+// ReadPalette reads a .pal file, three bytes of RGB for each color. A
+// file with 64 colors has the emphasized colors worked out from them,
+// one with 512 has them all, in the order SetPalette takes them.
+func ReadPalette(data []byte) ([]uint32, error) {
+	if len(data) != 64*3 && len(data) != 512*3 {
+		return nil, fmt.Errorf("Palette is %d bytes, expected 192 or 1536", len(data))
+	}
+
+	rgb := make([]uint32, len(data)/3)
+	for i := range rgb {
+		rgb[i] = uint32(data[i*3])<<16 | uint32(data[i*3+1])<<8 | uint32(data[i*3+2])
+	}
+
+	if len(rgb) == 64 {
+		return emphasize(rgb), nil
+	}
+
+	return rgb, nil
+}
+
+// LoadPalette reads the .pal file filename.
+func LoadPalette(filename string) ([]uint32, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadPalette(data)
+}
+
+// Each digit is one channel, 0-7
+var rgbPpuLevels = []uint16{
+	0333, 0014, 0006, 0326, 0403, 0503, 0510, 0420,
+	0320, 0120, 0031, 0040, 0022, 0000, 0000, 0000,
+	0555, 0036, 0027, 0407, 0507, 0704, 0700, 0630,
+	0430, 0140, 0040, 0053, 0044, 0000, 0000, 0000,
+	0777, 0357, 0447, 0637, 0707, 0737, 0740, 0750,
+	0660, 0360, 0070, 0276, 0077, 0000, 0000, 0000,
+	0777, 0567, 0657, 0757, 0747, 0755, 0764, 0772,
+	0773, 0572, 0473, 0276, 0467, 0000, 0000, 0000,
+}
+
+func rgbPpuPalette() []uint32 {
+	rgb := make([]uint32, 64)
+
+	for i, v := range rgbPpuLevels {
+		for shift := uint(0); shift < 9; shift += 3 {
+			rgb[i] |= uint32(v>>shift&0x7) * 255 / 7 << (shift / 3 * 8)
+		}
+	}
+
+	return rgb
+}
+
+// The RGB PPU doesn't dim anything for emphasis, each bit turns its
+// channel all the way up instead
+func emphasizeRgb(rgb []uint32) []uint32 {
+	palette := make([]uint32, 512)
+
+	for i := range palette {
+		c := rgb[i&0x3F]
+
+		if i&0x40 != 0 {
+			c |= 0xFF0000
+		}
+
+		if i&0x80 != 0 {
+			c |= 0x00FF00
+		}
+
+		if i&0x100 != 0 {
+			c |= 0x0000FF
+		}
+
+		palette[i] = c
+	}
+
+	return palette
+}
+
+var fceuxPalette = []uint32{
+	0x747474, 0x24188C, 0x0000A8, 0x44009C, 0x8C0074, 0xA80010, 0xA40000, 0x7C0800,
+	0x402C00, 0x004400, 0x005000, 0x003C14, 0x183C5C, 0x000000, 0x000000, 0x000000,
+	0xBCBCBC, 0x0070EC, 0x2038EC, 0x8000F0, 0xBC00BC, 0xE40058, 0xD82800, 0xC84C0C,
+	0x887000, 0x009400, 0x00A800, 0x009038, 0x008088, 0x000000, 0x000000, 0x000000,
+	0xFCFCFC, 0x3CBCFC, 0x5C94FC, 0xCC88FC, 0xF478FC, 0xFC74B4, 0xFC7460, 0xFC9838,
+	0xF0BC3C, 0x80D010, 0x4CDC48, 0x58F898, 0x00E8D8, 0x787878, 0x000000, 0x000000,
+	0xFCFCFC, 0xA8E4FC, 0xC4D4FC, 0xD4C8FC, 0xFCC4FC, 0xFCC4D8, 0xFCBCB0, 0xFCD8A8,
+	0xFCE4A0, 0xE0FCA0, 0xA8F0BC, 0xB0FCCC, 0x9CFCF0, 0xC4C4C4, 0x000000, 0x000000,
+}
+
+var nestopiaPalette = []uint32{
+	0x666666, 0x002A88, 0x1412A7, 0x3B00A4, 0x5C007E, 0x6E0040, 0x6C0600, 0x561D00,
+	0x333500, 0x0B4800, 0x005200, 0x004F08, 0x00404D, 0x000000, 0x000000, 0x000000,
+	0xADADAD, 0x155FD9, 0x4240FF, 0x7527FE, 0xA01ACC, 0xB71E7B, 0xB53120, 0x994E00,
+	0x6B6D00, 0x388700, 0x0C9300, 0x008F32, 0x007C8D, 0x000000, 0x000000, 0x000000,
+	0xFFFEFF, 0x64B0FF, 0x9290FF, 0xC676FF, 0xF36AFF, 0xFE6ECC, 0xFE8170, 0xEA9E22,
+	0xBCBE00, 0x88D800, 0x5CE430, 0x45E082, 0x48CDDE, 0x4F4F4F, 0x000000, 0x000000,
+	0xFFFEFF, 0xC0DFFF, 0xD3D2FF, 0xE8C8FF, 0xFBC2FF, 0xFEC4EA, 0xFECCC5, 0xF7D8A5,
+	0xE4E594, 0xCFEF96, 0xBDF4AB, 0xB3F3CC, 0xB5EBF2, 0xB8B8B8, 0x000000, 0x000000,
+}
@@ -0,0 +1,114 @@
+package ppu
+
+import (
+	"testing"
+)
+
+func TestReadPalette(test *testing.T) {
+	data := make([]byte, 64*3)
+	data[0x21*3], data[0x21*3+1], data[0x21*3+2] = 0x12, 0x34, 0x56
+
+	p, err := ReadPalette(data)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if len(p) != 512 || p[0x21] != 0x123456 {
+		test.Errorf("64 color palette read as %d colors, $21 is %06X", len(p), p[0x21])
+	}
+
+	// The emphasized colors are worked out
+	if p[0x61] == p[0x21] {
+		test.Errorf("Red emphasis didn't change $21")
+	}
+
+	data = make([]byte, 512*3)
+	data[0x161*3+2] = 0xFF
+
+	if p, err = ReadPalette(data); err != nil || p[0x161] != 0x0000FF {
+		test.Errorf("512 color palette read $161 as %06X, %v", p[0x161], err)
+	}
+
+	if _, err = ReadPalette(make([]byte, 100)); err == nil {
+		test.Errorf("Read a palette of 100 bytes")
+	}
+}
+
+func TestBuiltinPalettes(test *testing.T) {
+	for _, name := range []string{"2c02", "2c03", "fceux", "nestopia"} {
+		p, err := BuiltinPalette(name)
+		if err != nil || len(p) != 512 {
+			test.Errorf("Palette %s has %d colors, %v", name, len(p), err)
+		}
+	}
+
+	p, _ := BuiltinPalette("2c03")
+	if p[0x16] != 0xFF0000 {
+		test.Errorf("2c03 $16 was %06X, expected FF0000", p[0x16])
+	}
+
+	// Emphasis turns a channel up on the RGB PPU rather than dimming
+	// the others
+	if p[0x0F|0x80] != 0x00FF00 || p[0x16|0x100] != 0xFF00FF {
+		test.Errorf("2c03 emphasized $0F green as %06X and $16 blue as %06X", p[0x0F|0x80], p[0x16|0x100])
+	}
+
+	// Changing a palette that's been handed out doesn't change the
+	// next one
+	p[0x16] = 0
+	if p, _ = BuiltinPalette("2c03"); p[0x16] != 0xFF0000 {
+		test.Errorf("2c03 $16 was %06X after a copy was changed", p[0x16])
+	}
+
+	if _, err := BuiltinPalette("vga"); err == nil {
+		test.Errorf("Found a palette called vga")
+	}
+}
+
+func TestGeneratePalette(test *testing.T) {
+	p := GeneratePalette(DefaultNtsc)
+
+	if p[0x0F] != 0 || p[0x30] != 0xFFFFFF || p[0x20] != p[0x30] {
+		test.Errorf("Black was %06X and white %06X %06X", p[0x0F], p[0x20], p[0x30])
+	}
+
+	// $16 is red and $1A green
+	if r, g := p[0x16]>>16, p[0x16]>>8&0xFF; r < 0x80 || g > 0x40 {
+		test.Errorf("$16 was %06X, expected red", p[0x16])
+	}
+
+	if r, g := p[0x1A]>>16, p[0x1A]>>8&0xFF; g < 0x80 || r > 0x40 {
+		test.Errorf("$1A was %06X, expected green", p[0x1A])
+	}
+
+	// Grays have no chroma to turn
+	s := DefaultNtsc
+	s.Hue = 90
+
+	if turned := GeneratePalette(s); turned[0x10] != p[0x10] || turned[0x16] == p[0x16] {
+		test.Errorf("Turning the hue made $10 %06X and $16 %06X", turned[0x10], turned[0x16])
+	}
+
+	s = DefaultNtsc
+	s.Saturation = 0
+
+	if gray := GeneratePalette(s)[0x16]; gray>>16 != gray&0xFF {
+		test.Errorf("$16 without saturation was %06X", gray)
+	}
+}
+
+func TestPalettePerPpu(test *testing.T) {
+	a, b := new(Ppu), new(Ppu)
+	a.Init()
+	b.Init()
+
+	fceux, _ := BuiltinPalette("fceux")
+	a.SetPalette(fceux)
+
+	a.raster()
+	b.raster()
+
+	if a.Framebuffer[0] != fceuxPalette[0] || b.Framebuffer[0] != PaletteRgb[0] {
+		test.Errorf("Drew %06X and %06X, expected %06X and %06X", a.Framebuffer[0], b.Framebuffer[0], fceuxPalette[0], PaletteRgb[0])
+	}
+}
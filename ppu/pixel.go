@@ -0,0 +1,30 @@
+package ppu
+
+// Draws the pixel for the current dot of a visible scanline. The first
+// opaque sprite at a pixel wins over the sprites after it, even one
+// that's behind the background and so hidden by it, which lets a low
+// priority sprite mask out the sprites it overlaps.
+//
+// http://wiki.nesdev.com/w/index.php/PPU_sprite_priority
+func (p *Ppu) drawPixel() {
+	x := p.Cycle - 2
+
+	bg, attr := p.backgroundPixel(x)
+	i, spr := p.spriteAt(x)
+
+	p.checkSpriteZeroHit(x, bg)
+
+	pixel := Pixel{
+		p.colorIndex(p.bgPaletteEntry(attr, bg)),
+		int(bg),
+	}
+
+	if i >= 0 && (bg == 0 || p.spr.units[i].attr&0x20 == 0) {
+		pixel = Pixel{
+			p.colorIndex(p.sprPaletteEntry(p.spr.units[i].attr, spr)),
+			spr,
+		}
+	}
+
+	p.Palettebuffer[p.Scanline*256+x] = pixel
+}
@@ -0,0 +1,127 @@
+package ppu
+
+import (
+	"testing"
+)
+
+// Sprites are tile 2, color 2, and tile 3, solid color 3. The rest of
+// OAM is off screen.
+func newPriorityTestPpu(sprites []byte) *Ppu {
+	p := newRenderTestPpu()
+
+	for row := 0; row < 8; row++ {
+		p.ChrRam[0x30+row] = 0xFF
+		p.ChrRam[0x38+row] = 0xFF
+	}
+
+	for i := 0; i < 0x100; i += 4 {
+		p.SpriteRam[i] = 0xF0
+	}
+
+	copy(p.SpriteRam[:], sprites)
+
+	return p
+}
+
+func TestSpritePriority(test *testing.T) {
+	// Sprite 0 is behind the background at x=124, sprite 1 in front of
+	// it at x=126
+	p := newPriorityTestPpu([]byte{
+		3, 2, 0x20, 124,
+		3, 3, 0x00, 126,
+	})
+	p.WriteMask(0x1E)
+
+	// The left half of the screen is opaque
+	fillNametable(p, 0x2000, func(row, col int) byte {
+		if col < 16 {
+			return 1
+		}
+
+		return 0
+	})
+
+	runToDot(p, 5, 0)
+
+	// Where sprite 0 is hidden by the background it hides sprite 1 too
+	verifyRow(p, 4, func(x int) int {
+		switch {
+		case x < 128:
+			return 1
+		case x < 132:
+			return 2
+		case x < 134:
+			return 3
+		}
+
+		return 0
+	}, test)
+}
+
+func TestLeftClipping(test *testing.T) {
+	masks := []struct {
+		mask     byte
+		expected int
+	}{
+		{0x18, 0},
+		{0x1A, 1},
+		{0x1C, 3},
+		{0x1E, 3},
+	}
+
+	for _, m := range masks {
+		p := newPriorityTestPpu([]byte{3, 3, 0x00, 0})
+		p.WriteMask(m.mask)
+
+		fillNametable(p, 0x2000, func(row, col int) byte { return 1 })
+
+		runToDot(p, 5, 0)
+
+		verifyRow(p, 4, func(x int) int {
+			if x < 8 {
+				return m.expected
+			}
+
+			return 1
+		}, test)
+	}
+}
+
+func TestGrayscaleAndEmphasis(test *testing.T) {
+	p := newRenderTestPpu()
+	p.PaletteRam[0x01] = 0x16
+
+	fillNametable(p, 0x2000, func(row, col int) byte { return 1 })
+
+	// Grayscale with red emphasis from scanline 2 on
+	runToDot(p, 2, 0)
+	p.WriteMask(0x2B)
+
+	runToDot(p, 3, 0)
+
+	if i := p.Palettebuffer[1*256].Index; i != 0x16 {
+		test.Errorf("Scanline 1 was drawn in %03X, expected 016", i)
+	}
+
+	if i := p.Palettebuffer[2*256].Index; i != 0x50 {
+		test.Errorf("Scanline 2 was drawn in %03X, expected 050", i)
+	}
+}
+
+func TestEmphasisPalette(test *testing.T) {
+	palette := emphasize(PaletteRgb)
+
+	if len(palette) != 512 || palette[0x16] != PaletteRgb[0x16] {
+		test.Errorf("Colors without emphasis don't match PaletteRgb")
+	}
+
+	// Red emphasis keeps red and darkens green and blue
+	c, e := PaletteRgb[0x20], palette[0x60]
+	if e>>16 != c>>16 || e&0xFF >= c&0xFF || e>>8&0xFF >= c>>8&0xFF {
+		test.Errorf("Red emphasis turned %06X into %06X", c, e)
+	}
+
+	if palette[0x1CF] != PaletteRgb[0x0F] {
+		test.Errorf("Emphasis changed black")
+	}
+}
@@ -0,0 +1,574 @@
+// Package ppu implements the NES's Ricoh 2C02 picture processing unit
+package ppu
+
+import (
+	"github.com/scottferg/Fergulator/cpu"
+	"math"
+)
+
+const (
+	StatusSpriteOverflow = iota
+	StatusSprite0Hit
+	StatusVblankStarted
+)
+
+type Flags struct {
+	BaseNametableAddress     byte
+	VramAddressInc           byte
+	SpritePatternAddress     byte
+	BackgroundPatternAddress byte
+	SpriteSize               byte
+	MasterSlaveSel           byte
+	NmiOnVblank              byte
+}
+
+// Index is the 9 bit color the pixel is drawn in, an entry of the PPU's
+// palette.
+// Value is the 2 bit pattern value of the tile or sprite it came from.
+type Pixel struct {
+	Index uint16
+	Value int
+}
+
+type Masks struct {
+	Grayscale            bool
+	ShowBackgroundOnLeft bool
+	ShowSpritesOnLeft    bool
+	ShowBackground       bool
+	ShowSprites          bool
+	IntensifyReds        bool
+	IntensifyGreens      bool
+	IntensifyBlues       bool
+}
+
+type Registers struct {
+	Control          byte
+	Mask             byte
+	Status           byte
+	VramDataBuffer   byte
+	VramAddress      int
+	VramLatch        int
+	SpriteRamAddress int
+	FineX            byte
+	Data             byte
+	WriteLatch       bool
+	HighBitShift     uint16
+	LowBitShift      uint16
+
+	AttributeHighShift uint16
+	AttributeLowShift  uint16
+}
+
+// Mapper is the part of the cartridge that watches PPU timing
+type Mapper interface {
+	Hook()
+}
+
+type Ppu struct {
+	Cpu *cpu.Cpu
+	Rom Mapper
+
+	Registers
+	Flags
+	Masks
+	// Pattern tables are mapped in 1k pages. Until a cartridge maps
+	// its CHR-ROM they point at ChrRam.
+	ChrRam            [0x2000]byte
+	chrPages          [8][]byte
+	chrWritable       [8]bool
+	SpriteRam         [0x100]byte
+	Nametables        Nametable
+	PaletteRam        [0x20]byte
+	AttributeLocation [0x400]uint
+	AttributeShift    [0x400]uint
+
+	// Background tile being fetched
+	bg background
+
+	// Sprite evaluation and the sprite units
+	spr sprites
+
+	Palettebuffer []Pixel
+
+	// The RGB color for each 9 bit color
+	palette []uint32
+
+	// Filled in at the start of vblank. The console swaps it out for
+	// a fresh buffer once the frame is finished.
+	Framebuffer []uint32
+
+	Cycle       int
+	Scanline    int
+	Timestamp   int
+	VblankTime  int
+	FrameCount  int
+	FrameCycles int
+
+	// Last scanline of vertical blank, 260 on NTSC and 310 on PAL
+	LastScanline int
+
+	SuppressVbl bool
+}
+
+func (p *Ppu) Init() {
+	p.WriteLatch = true
+
+	p.Cycle = 0
+	p.Scanline = -1
+	p.FrameCount = 0
+
+	p.VblankTime = 20 * 341 * 5 // NTSC
+	p.LastScanline = 260
+
+	for i, _ := range p.ChrRam {
+		p.ChrRam[i] = 0x00
+	}
+
+	for i := 0; i < 0x2000; i += 0x400 {
+		p.MapChr(i, p.ChrRam[i:i+0x400], true)
+	}
+
+	for i, _ := range p.SpriteRam {
+		p.SpriteRam[i] = 0x00
+	}
+
+	for i, _ := range p.AttributeShift {
+		x := uint(i)
+		p.AttributeShift[i] = ((x >> 4) & 0x04) | (x & 0x02)
+		p.AttributeLocation[i] = ((x >> 2) & 0x07) | (((x >> 4) & 0x38) | 0x3C0)
+	}
+
+	p.palette = defaultPalette
+	p.Palettebuffer = make([]Pixel, 0xF000)
+	p.Framebuffer = make([]uint32, 0xF000)
+}
+
+// SetPalette picks the RGB colors the frame is drawn in, one for each of
+// the 512 9 bit colors, as BuiltinPalette, GeneratePalette and
+// ReadPalette return them. It takes a copy, and like the rest of the
+// PPU isn't safe to call while another goroutine is running it.
+func (p *Ppu) SetPalette(palette []uint32) {
+	p.palette = make([]uint32, 512)
+	copy(p.palette, palette)
+}
+
+func (p *Ppu) PpuRegRead(a int) byte {
+	switch a & 0x7 {
+	case 0x2:
+		return p.ReadStatus()
+	case 0x4:
+		return p.ReadOamData()
+	case 0x7:
+		return p.ReadData()
+	}
+
+	return 0
+}
+
+func (p *Ppu) PpuRegWrite(v byte, a int) {
+	switch a & 0x7 {
+	case 0x0:
+		p.WriteControl(v)
+	case 0x1:
+		p.WriteMask(v)
+	case 0x3:
+		p.WriteOamAddress(v)
+	case 0x4:
+		p.WriteOamData(v)
+	case 0x5:
+		p.WriteScroll(v)
+	case 0x6:
+		p.WriteAddress(v)
+	case 0x7:
+		p.WriteData(v)
+	}
+}
+
+// MapChr points the 1k pattern table page holding a at bank.
+// Writes through $2007 to a page that isn't writable are dropped.
+func (p *Ppu) MapChr(a int, bank []byte, writable bool) {
+	p.chrPages[a>>10&0x7] = bank[:0x400]
+	p.chrWritable[a>>10&0x7] = writable
+}
+
+func (p *Ppu) readChr(a int) byte {
+	return p.chrPages[a>>10&0x7][a&0x3FF]
+}
+
+func (p *Ppu) writeChr(a int, v byte) {
+	if p.chrWritable[a>>10&0x7] {
+		p.chrPages[a>>10&0x7][a&0x3FF] = v
+	}
+}
+
+// Writes to mirrored regions of VRAM
+func (p *Ppu) writeMirroredVram(a int, v byte) {
+	if a >= 0x3F00 {
+		if a&0xF == 0 {
+			a = 0
+		}
+		p.PaletteRam[a&0x1F] = v
+	} else {
+		p.Nametables.WriteNametableData(a-0x1000, v)
+	}
+}
+
+func (p *Ppu) raster() {
+	length := len(p.Palettebuffer)
+	for i := length - 1; i >= 0; i-- {
+		y := int(math.Floor(float64(i / 256)))
+		x := i - (y * 256)
+
+		p.Framebuffer[(y*256)+x] = p.palette[p.Palettebuffer[i].Index]
+		p.Palettebuffer[i].Value = 0
+	}
+}
+
+func (p *Ppu) Step() {
+	switch {
+	case p.Scanline == 240:
+		if p.Cycle == 1 {
+			p.raster()
+		}
+	case p.Scanline == 241:
+		if p.Cycle == 2 {
+			if !p.SuppressVbl {
+				// We're in VBlank
+				p.setStatus(StatusVblankStarted)
+			}
+
+			p.SuppressVbl = false
+		}
+	case p.Scanline == p.LastScanline: // End of vblank
+		if p.Cycle == 341 {
+			p.Scanline = -1
+			p.Cycle = 1
+			p.FrameCount++
+			return
+		}
+	case p.Scanline < 240 && p.Scanline > -1:
+		p.renderBackground()
+		p.renderSprites()
+
+		if p.Cycle >= 2 && p.Cycle <= 257 {
+			p.drawPixel()
+		} else if p.Cycle == 260 {
+			// MMC3 IRQ, otherwise nothing
+			if p.Rom != nil {
+				p.Rom.Hook()
+			}
+		}
+	case p.Scanline == -1:
+		if p.Cycle == 2 {
+			// Clear VBlank flag
+			p.clearStatus(StatusVblankStarted)
+
+			p.clearStatus(StatusSprite0Hit)
+			p.clearStatus(StatusSpriteOverflow)
+		}
+
+		p.renderBackground()
+		p.renderSprites()
+	}
+
+	if p.Cycle == 341 {
+		p.Cycle = 0
+		p.Scanline++
+	}
+
+	p.Cycle++
+}
+
+// $2000
+func (p *Ppu) WriteControl(v byte) {
+	p.Control = v
+
+	// Control flag
+	// 7654 3210
+	// |||| ||||
+	// |||| ||++- Base nametable address
+	// |||| ||    (0 = $2000; 1 = $2400; 2 = $2800; 3 = $2C00)
+	// |||| |+--- VRAM address increment per CPU read/write of PPUDATA
+	// |||| |     (0: increment by 1, going across; 1: increment by 32, going down)
+	// |||| +---- Sprite pattern table address for 8x8 sprites
+	// ||||       (0: $0000; 1: $1000; ignored in 8x16 mode)
+	// |||+------ Background pattern table address (0: $0000; 1: $1000)
+	// ||+------- Sprite size (0: 8x8; 1: 8x16)
+	// |+-------- PPU master/slave select (has no effect on the NES)
+	// +--------- Generate an NMI at the start of the
+	//            vertical blanking interval (0: off; 1: on)
+	p.BaseNametableAddress = v & 0x03
+	p.VramAddressInc = (v >> 2) & 0x01
+	p.SpritePatternAddress = (v >> 3) & 0x01
+	p.BackgroundPatternAddress = (v >> 4) & 0x01
+	p.SpriteSize = (v >> 5) & 0x01
+	p.NmiOnVblank = (v >> 7) & 0x01
+
+	p.VramLatch = (p.VramLatch & 0xF3FF) | (int(p.BaseNametableAddress) << 10)
+
+	p.updateNmi()
+}
+
+// $2001
+func (p *Ppu) WriteMask(v byte) {
+	p.Mask = v
+
+	// 76543210
+	// ||||||||
+	// |||||||+- Grayscale (0: normal color; 1: produce a monochrome display)
+	// ||||||+-- 1: Show background in leftmost 8 pixels of screen; 0: Hide
+	// |||||+--- 1: Show sprites in leftmost 8 pixels of screen; 0: Hide
+	// ||||+---- 1: Show background
+	// |||+----- 1: Show sprites
+	// ||+------ Intensify reds (and darken other colors)
+	// |+------- Intensify greens (and darken other colors)
+	// +-------- Intensify blues (and darken other colors)
+	p.Grayscale = (v&0x01 == 0x01)
+	p.ShowBackgroundOnLeft = (((v >> 1) & 0x01) == 0x01)
+	p.ShowSpritesOnLeft = (((v >> 2) & 0x01) == 0x01)
+	p.ShowBackground = (((v >> 3) & 0x01) == 0x01)
+	p.ShowSprites = (((v >> 4) & 0x01) == 0x01)
+	p.IntensifyReds = (((v >> 5) & 0x01) == 0x01)
+	p.IntensifyGreens = (((v >> 6) & 0x01) == 0x01)
+	p.IntensifyBlues = (((v >> 7) & 0x01) == 0x01)
+}
+
+func (p *Ppu) clearStatus(s byte) {
+	switch s {
+	case StatusSpriteOverflow:
+		p.Status = p.Status & 0xDF
+	case StatusSprite0Hit:
+		p.Status = p.Status & 0xBF
+	case StatusVblankStarted:
+		p.Status = p.Status & 0x7F
+	}
+
+	p.updateNmi()
+}
+
+func (p *Ppu) setStatus(s byte) {
+	switch s {
+	case StatusSpriteOverflow:
+		p.Status = p.Status | 0x20
+	case StatusSprite0Hit:
+		p.Status = p.Status | 0x40
+	case StatusVblankStarted:
+		p.Status = p.Status | 0x80
+	}
+
+	p.updateNmi()
+}
+
+// The PPU holds the NMI line active for as long as the VBlank flag
+// is set and $2000.7 enables NMIs. Turning NMIs on during VBlank
+// makes another edge, and another NMI.
+func (p *Ppu) updateNmi() {
+	if p.Cpu != nil {
+		p.Cpu.SetNmi(p.NmiOnVblank == 0x1 && p.Status&0x80 != 0)
+	}
+}
+
+// $2002
+func (p *Ppu) ReadStatus() (s byte) {
+	p.WriteLatch = true
+	s = p.Status
+
+	if p.Cycle == 2 && p.Scanline == 241 {
+		// Reading just as VBlank starts means the flag, and the NMI
+		// with it, never gets set this frame
+		s &= 0x7F
+		p.SuppressVbl = true
+	} else {
+		p.SuppressVbl = false
+		// Clear VBlank flag
+		p.clearStatus(StatusVblankStarted)
+	}
+
+	return
+}
+
+// $2003
+func (p *Ppu) WriteOamAddress(v byte) {
+	p.SpriteRamAddress = int(v)
+}
+
+// $2004
+func (p *Ppu) WriteOamData(v byte) {
+	p.SpriteRam[p.SpriteRamAddress] = v
+
+	p.SpriteRamAddress++
+	p.SpriteRamAddress %= 0x100
+}
+
+// $2004
+func (p *Ppu) ReadOamData() byte {
+	return p.SpriteRam[p.SpriteRamAddress]
+}
+
+// $2005
+func (p *Ppu) WriteScroll(v byte) {
+	if p.WriteLatch {
+		p.VramLatch = p.VramLatch & 0x7FE0
+		p.VramLatch = p.VramLatch | ((int(v) & 0xF8) >> 3)
+		p.FineX = v & 0x07
+	} else {
+		p.VramLatch = p.VramLatch & 0xC1F
+		p.VramLatch = p.VramLatch | (((int(v) & 0xF8) << 2) | ((int(v) & 0x07) << 12))
+	}
+
+	p.WriteLatch = !p.WriteLatch
+}
+
+// $2006
+func (p *Ppu) WriteAddress(v byte) {
+	if p.WriteLatch {
+		p.VramLatch = p.VramLatch & 0xFF
+		p.VramLatch = p.VramLatch | ((int(v) & 0x3F) << 8)
+	} else {
+		p.VramLatch = p.VramLatch & 0x7F00
+		p.VramLatch = p.VramLatch | int(v)
+		p.VramAddress = p.VramLatch
+	}
+
+	p.WriteLatch = !p.WriteLatch
+}
+
+// $2007
+func (p *Ppu) WriteData(v byte) {
+	a := p.VramAddress & 0x3FFF
+
+	if a >= 0x3000 {
+		p.writeMirroredVram(a, v)
+	} else if a >= 0x2000 {
+		// Nametable mirroring
+		p.Nametables.WriteNametableData(a, v)
+	} else {
+		p.writeChr(a, v)
+	}
+
+	p.incrementVramAddress()
+}
+
+// $2007
+func (p *Ppu) ReadData() (r byte) {
+	// Reads from $2007 are buffered with a
+	// 1-byte delay
+	a := p.VramAddress & 0x3FFF
+
+	if a < 0x2000 {
+		r = p.VramDataBuffer
+		p.VramDataBuffer = p.readChr(a)
+	} else if a < 0x3000 {
+		r = p.VramDataBuffer
+		p.VramDataBuffer = p.Nametables.ReadNametableData(a)
+	} else if a < 0x3F00 {
+		r = p.VramDataBuffer
+		p.VramDataBuffer = p.Nametables.ReadNametableData(a - 0x1000)
+	} else {
+		// Palette reads aren't buffered, but the buffer still
+		// picks up the nametable byte underneath
+		p.VramDataBuffer = p.Nametables.ReadNametableData(a - 0x1000)
+
+		if a&0xF == 0 {
+			a = 0
+		}
+
+		r = p.PaletteRam[a&0x1F]
+	}
+
+	p.incrementVramAddress()
+
+	return
+}
+
+// v is 15 bits wide and wraps around
+func (p *Ppu) incrementVramAddress() {
+	switch p.VramAddressInc {
+	case 0x01:
+		p.VramAddress = (p.VramAddress + 0x20) & 0x7FFF
+	default:
+		p.VramAddress = (p.VramAddress + 0x01) & 0x7FFF
+	}
+}
+
+func (p *Ppu) sprPatternTableAddress(i int) int {
+	if p.SpriteSize&0x01 != 0x0 {
+		// 8x16 Sprites
+		if i&0x01 != 0 {
+			return 0x1000 | ((int(i) >> 1) * 0x20)
+		} else {
+			return ((int(i) >> 1) * 0x20)
+		}
+
+	}
+
+	// 8x8 Sprites
+	var a int
+	if p.SpritePatternAddress == 0x01 {
+		a = 0x1000
+	} else {
+		a = 0x0
+	}
+
+	return int(i)*0x10 + a
+}
+
+func (p *Ppu) bgPatternTableAddress(i byte) int {
+	var a int
+	if p.BackgroundPatternAddress == 0x01 {
+		a = 0x1000
+	} else {
+		a = 0x0
+	}
+
+	return (int(i) << 4) | (p.VramAddress >> 12) | a
+}
+
+// The 9 bit color for palette entry c, with the grayscale and emphasis
+// bits of PPUMASK as they are for this dot
+func (p *Ppu) colorIndex(c int) uint16 {
+	i := uint16(c) & 0x3F
+
+	if p.Grayscale {
+		i &= 0x30
+	}
+
+	if p.IntensifyReds {
+		i |= 0x40
+	}
+
+	if p.IntensifyGreens {
+		i |= 0x80
+	}
+
+	if p.IntensifyBlues {
+		i |= 0x100
+	}
+
+	return i
+}
+
+func (p *Ppu) bgPaletteEntry(a byte, pix uint16) (pal int) {
+	if pix == 0x0 {
+		return int(p.PaletteRam[0x00])
+	}
+
+	switch a {
+	case 0x0:
+		return int(p.PaletteRam[0x00+pix])
+	case 0x4:
+		return int(p.PaletteRam[0x04+pix])
+	case 0x8:
+		return int(p.PaletteRam[0x08+pix])
+	case 0xC:
+		return int(p.PaletteRam[0x0C+pix])
+	}
+
+	return
+}
+
+// Sprites use the four palettes from $3F10. Color 0 is transparent so
+// it's never looked up.
+func (p *Ppu) sprPaletteEntry(a byte, pix int) int {
+	return int(p.PaletteRam[0x10+int(a&0x3)*4+pix])
+}
@@ -1,4 +1,4 @@
-package main
+package ppu
 
 import (
 	"testing"
@@ -8,9 +8,9 @@ var (
 	p *Ppu
 )
 
-func verifyValue(a int, v Word, test *testing.T) {
-	if p.Nametables.readNametableData(a) != v {
-		test.Errorf("0x%X was 0x%X, expected 0x%X\n", a, p.Vram[0x2000], v)
+func verifyValue(a int, v byte, test *testing.T) {
+	if p.Nametables.ReadNametableData(a) != v {
+		test.Errorf("0x%X was 0x%X, expected 0x%X\n", a, p.Nametables.ReadNametableData(a), v)
 	}
 }
 
@@ -18,7 +18,7 @@ func TestVerticalNametableMirroring(test *testing.T) {
 	p = new(Ppu)
 	p.Init()
 
-    p.Nametables.SetMirroring(MirroringVertical)
+	p.Nametables.SetMirroring(MirroringVertical)
 
 	p.VramAddress = 0x2000
 	p.WriteData(0x11)
@@ -77,7 +77,7 @@ func TestHorizontalNametableMirroring(test *testing.T) {
 	p = new(Ppu)
 	p.Init()
 
-    p.Nametables.SetMirroring(MirroringHorizontal)
+	p.Nametables.SetMirroring(MirroringHorizontal)
 
 	p.VramAddress = 0x2000
 	p.WriteData(0x11)
@@ -131,3 +131,35 @@ func TestHorizontalNametableMirroring(test *testing.T) {
 	verifyValue(0x2B38, 0x55, test)
 	verifyValue(0x2F38, 0x55, test)
 }
+
+func TestStepWithoutMapper(test *testing.T) {
+	p = new(Ppu)
+	p.Init()
+	p.Nametables.SetMirroring(MirroringVertical)
+	p.WriteMask(0x18)
+
+	for p.FrameCount == 0 {
+		p.Step()
+	}
+}
+
+func TestVramAddressWraps(test *testing.T) {
+	p = new(Ppu)
+	p.Init()
+	p.Nametables.SetMirroring(MirroringVertical)
+
+	p.VramAddress = 0x7FFF
+	p.WriteData(0x00)
+
+	if p.VramAddress != 0x0000 {
+		test.Errorf("VRAM address was 0x%X after 0x7FFF, expected 0x0", p.VramAddress)
+	}
+
+	p.WriteControl(0x04)
+	p.VramAddress = 0x7FF0
+	p.ReadData()
+
+	if p.VramAddress != 0x0010 {
+		test.Errorf("VRAM address was 0x%X after 0x7FF0, expected 0x10", p.VramAddress)
+	}
+}
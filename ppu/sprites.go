@@ -0,0 +1,244 @@
+package ppu
+
+// Sprites are found a scanline ahead. While a scanline is drawn the
+// PPU clears the 32 byte secondary OAM over dots 1-64, then over dots
+// 65-256 reads through OAM a byte every other dot, copying the first
+// eight sprites that fall on the scanline into secondary OAM. Their
+// patterns are fetched into the eight sprite units over dots 257-320,
+// and the units draw them on the next scanline. A sprite's Y is one
+// less than the first scanline it shows on.
+//
+// http://wiki.nesdev.com/w/index.php/PPU_sprite_evaluation
+type spriteUnit struct {
+	x    int
+	attr byte
+
+	// One row of the pattern, flipped so bit 7 is the leftmost pixel
+	low  byte
+	high byte
+}
+
+type sprites struct {
+	secondary [32]byte
+
+	// Evaluation reads byte m of sprite n
+	n       int
+	m       int
+	found   int
+	copying bool
+	done    bool
+
+	// Sprite 0 is in secondary OAM, and in the first unit once the
+	// units are loaded from it
+	zeroFound  bool
+	zeroLoaded bool
+
+	units [8]spriteUnit
+}
+
+func (p *Ppu) spriteHeight() int {
+	if p.SpriteSize&0x01 != 0 {
+		return 16
+	}
+
+	return 8
+}
+
+func (p *Ppu) spriteInRange(y byte) bool {
+	row := p.Scanline - int(y)
+	return row >= 0 && row < p.spriteHeight()
+}
+
+// Runs sprite evaluation and fetches for the current dot of the
+// pre-render or a visible scanline
+func (p *Ppu) renderSprites() {
+	if !p.renderingEnabled() {
+		return
+	}
+
+	dot := p.Cycle - 1
+
+	switch {
+	case p.Scanline < 0:
+		// No sprites are found on the pre-render scanline, so none
+		// are drawn on the first
+		if dot >= 257 && dot <= 320 && (dot-257)&0x7 == 7 {
+			p.spr.units[(dot-257)/8] = spriteUnit{}
+			p.spr.zeroLoaded = false
+		}
+	case dot >= 1 && dot <= 64:
+		if dot&0x1 == 0 {
+			p.spr.secondary[dot/2-1] = 0xFF
+		}
+	case dot >= 65 && dot <= 256:
+		if dot == 65 {
+			p.spr.n, p.spr.m, p.spr.found = 0, 0, 0
+			p.spr.copying, p.spr.done, p.spr.zeroFound = false, false, false
+		}
+
+		// Odd dots read OAM, even ones write secondary OAM
+		if dot&0x1 == 0 {
+			p.evaluateSprite()
+		}
+	case dot >= 257 && dot <= 320:
+		// Each sprite takes eight dots, the last two fetch the high
+		// plane of its pattern
+		if (dot-257)&0x7 == 7 {
+			p.fetchSprite((dot - 257) / 8)
+		}
+	}
+}
+
+func (p *Ppu) evaluateSprite() {
+	s := &p.spr
+	if s.done {
+		return
+	}
+
+	v := p.SpriteRam[s.n*4+s.m]
+
+	switch {
+	case s.copying:
+		// The rest of a sprite that's in range
+		s.secondary[s.found*4+s.m] = v
+		s.m++
+
+		if s.m == 4 {
+			s.m = 0
+			s.copying = false
+			s.found++
+			s.nextSprite()
+		}
+	case s.found < 8:
+		// Y is copied whether or not the sprite is in range, the slot
+		// only moves on for one that is
+		s.secondary[s.found*4] = v
+
+		if p.spriteInRange(v) {
+			s.zeroFound = s.zeroFound || s.n == 0
+			s.copying = true
+			s.m = 1
+		} else {
+			s.nextSprite()
+		}
+	default:
+		// Secondary OAM is full, but the PPU goes on looking for a
+		// ninth sprite to set the overflow flag. It moves m along with
+		// n when the sprite isn't in range, so it checks the tile,
+		// attribute and X bytes of the sprites after as if they were
+		// Y coordinates.
+		if p.spriteInRange(v) {
+			p.setStatus(StatusSpriteOverflow)
+			s.done = true
+		} else {
+			s.m = (s.m + 1) & 0x3
+			s.nextSprite()
+		}
+	}
+}
+
+func (s *sprites) nextSprite() {
+	s.n++
+	s.done = s.n == 64
+}
+
+// Loads sprite unit i from secondary OAM
+func (p *Ppu) fetchSprite(i int) {
+	u := &p.spr.units[i]
+
+	if i == 0 {
+		p.spr.zeroLoaded = p.spr.zeroFound
+	}
+
+	if i >= p.spr.found {
+		*u = spriteUnit{}
+		return
+	}
+
+	y := p.spr.secondary[i*4]
+	tile := p.spr.secondary[i*4+1]
+	u.attr = p.spr.secondary[i*4+2]
+	u.x = int(p.spr.secondary[i*4+3])
+
+	height := p.spriteHeight()
+	row := (p.Scanline - int(y)) & (height - 1)
+
+	if u.attr&0x80 != 0 {
+		row = height - 1 - row
+	}
+
+	// The bottom half of an 8x16 sprite is the next tile
+	a := p.sprPatternTableAddress(int(tile))
+	if row > 7 {
+		a += 16
+		row -= 8
+	}
+
+	u.low = p.readChr(a + row)
+	u.high = p.readChr(a + row + 8)
+
+	if u.attr&0x40 != 0 {
+		u.low = reverseBits(u.low)
+		u.high = reverseBits(u.high)
+	}
+}
+
+func reverseBits(b byte) byte {
+	var r byte
+
+	for i := 0; i < 8; i++ {
+		r = r<<1 | b&0x1
+		b >>= 1
+	}
+
+	return r
+}
+
+// The pixel sprite unit i draws at x, 0 where it's transparent or
+// doesn't cover x
+func (p *Ppu) spritePixel(i, x int) int {
+	u := &p.spr.units[i]
+
+	o := x - u.x
+	if o < 0 || o > 7 {
+		return 0
+	}
+
+	shift := uint(7 - o)
+	return int((u.low>>shift)&0x1 | (u.high>>shift)&0x1<<1)
+}
+
+// The first sprite unit with an opaque pixel at x, which is the one
+// drawn there whatever its priority. -1 where sprites are hidden or
+// clipped, or all of them are transparent.
+func (p *Ppu) spriteAt(x int) (i, pixel int) {
+	if !p.ShowSprites || x < 8 && !p.ShowSpritesOnLeft {
+		return -1, 0
+	}
+
+	for i := range p.spr.units {
+		if pixel := p.spritePixel(i, x); pixel != 0 {
+			return i, pixel
+		}
+	}
+
+	return -1, 0
+}
+
+// Sprite 0 hits where an opaque pixel of it is drawn over an opaque
+// background pixel. It can't hit at x=255, or in the left 8 pixels
+// while either layer is clipped there.
+func (p *Ppu) checkSpriteZeroHit(x int, bg uint16) {
+	switch {
+	case !p.spr.zeroLoaded || p.Status&0x40 != 0:
+		return
+	case !p.ShowBackground || !p.ShowSprites || x == 255:
+		return
+	case x < 8 && (!p.ShowBackgroundOnLeft || !p.ShowSpritesOnLeft):
+		return
+	}
+
+	if bg != 0 && p.spritePixel(0, x) != 0 {
+		p.setStatus(StatusSprite0Hit)
+	}
+}
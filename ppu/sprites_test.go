@@ -0,0 +1,78 @@
+package ppu
+
+import (
+	"testing"
+)
+
+// Eight sprites on scanline 10 fill secondary OAM. The rest are off
+// screen, except that sprite 9's tile number is 10.
+func newOverflowTestPpu(tile byte) *Ppu {
+	p := newRenderTestPpu()
+	p.WriteMask(0x1E)
+
+	for i := 0; i < 0x100; i += 4 {
+		p.SpriteRam[i] = 0xF0
+	}
+
+	for i := 0; i < 8; i++ {
+		p.SpriteRam[i*4] = 10
+		p.SpriteRam[i*4+3] = byte(i * 8)
+	}
+
+	p.SpriteRam[9*4+1] = tile
+
+	return p
+}
+
+func TestSpriteOverflowBug(test *testing.T) {
+	// Sprite 8 isn't in range, so the PPU moves on to the tile number
+	// of sprite 9 and takes it for a Y coordinate
+	p := newOverflowTestPpu(10)
+	runToDot(p, 10, 257)
+
+	if p.Status&0x20 == 0 {
+		test.Errorf("Overflow wasn't set by sprite 9's tile number")
+	}
+
+	if p.spr.found != 8 || !p.spr.zeroFound {
+		test.Errorf("Found %d sprites, sprite 0 found %t", p.spr.found, p.spr.zeroFound)
+	}
+
+	p = newOverflowTestPpu(0x80)
+	runToDot(p, 10, 257)
+
+	if p.Status&0x20 != 0 {
+		test.Errorf("Overflow was set without a ninth sprite")
+	}
+}
+
+func TestSpriteUnitsFlip(test *testing.T) {
+	p := newRenderTestPpu()
+	p.WriteMask(0x1E)
+
+	// Tile 3's top row is a single pixel on the left
+	p.ChrRam[0x30] = 0x80
+
+	for i := 0; i < 0x100; i += 4 {
+		p.SpriteRam[i] = 0xF0
+	}
+
+	// Sprite 0 as is at x=16, sprite 1 flipped both ways at x=32
+	copy(p.SpriteRam[:], []byte{
+		4, 3, 0x00, 16,
+		4, 3, 0xC0, 32,
+	})
+
+	// Row 7 of the flipped sprite is row 0 of the tile
+	runToDot(p, 5, 1)
+
+	if p.spritePixel(0, 16) != 1 || p.spritePixel(0, 17) != 0 {
+		test.Errorf("Sprite 0 drew %d %d, expected 1 0", p.spritePixel(0, 16), p.spritePixel(0, 17))
+	}
+
+	runToDot(p, 12, 1)
+
+	if p.spritePixel(1, 39) != 1 || p.spritePixel(1, 32) != 0 {
+		test.Errorf("Flipped sprite drew %d at x=39, expected 1", p.spritePixel(1, 39))
+	}
+}